@@ -6,12 +6,15 @@ import (
 	"content-flow/internal/models"
 	"content-flow/internal/pkgs/apierrors"
 	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/queue"
 	"content-flow/internal/services"
+	"context"
 	"log"
 	"time"
 
 	_ "content-flow/docs" // Import generated swagger docs
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -37,11 +40,17 @@ func main() {
 
 	// 2. Run Auto-Migrations
 	log.Println("Running Auto-migrations...")
-	database.DB.AutoMigrate(&models.Content{}, &models.ContentVersion{}, &models.Media{}, &models.User{}, &models.Category{}, &models.Tag{}, &models.Webhook{}, &models.Comment{}, &models.Like{}, &models.Role{}, &models.Permission{})
+	database.DB.AutoMigrate(&models.Content{}, &models.ContentVersion{}, &models.Media{}, &models.User{}, &models.Category{}, &models.Tag{}, &models.Webhook{}, &models.Comment{}, &models.Like{}, &models.Role{}, &models.Permission{}, &models.ContentType{}, &models.BlockType{}, &models.ContentReference{}, &models.ContentSlugHistory{}, &models.WorkflowTransition{}, &models.ContentTransition{}, &models.ReviewRequest{}, &models.ReviewAssignment{}, &models.Job{}, &models.Lock{}, &models.ContentDraft{})
+
+	// Full-text search tables and SQL functions live outside GORM's migrations
+	log.Println("Setting up search index...")
+	services.SetupSearchIndex()
+	services.SetupAttributeQueries()
 
 	// Seed RBAC
 	log.Println("Seeding RBAC...")
 	services.SeedRBAC()
+	services.SeedWorkflow()
 
 	// 3. Setup Fiber App with Global Error Handler and Limits
 	app := fiber.New(fiber.Config{
@@ -73,21 +82,37 @@ func main() {
 	})
 
 	// 4. Setup Routes
+	// GraphQL (anonymous reads; mutations check permissions per field)
+	app.Get("/graphql", auth.Optional(), handlers.GraphQL)
+	app.Post("/graphql", auth.Optional(), handlers.GraphQL)
+
 	api := app.Group("/api")
 
 	// Public Taxonomy Routes
 	api.Get("/categories", handlers.GetAllCategories)
 	api.Get("/tags", handlers.GetAllTags)
 
+	// Public Content Type Registry (editors build forms from the schemas)
+	api.Get("/content-types", handlers.GetAllContentTypes)
+	api.Get("/content-types/:id", handlers.GetContentType)
+	api.Get("/block-types", handlers.GetBlockDefinitions)
+
 	// Public Read Access for Content
-	// Public Read Access for Content
-	api.Get("/content", handlers.GetAllContent)
-	api.Get("/content/:id", handlers.GetContent)
-	api.Get("/content/:id/comments", handlers.GetComments)
+	// Anonymous visitors only see published content; a token widens that (drafts for
+	// content.read, and authors' own items)
+	api.Get("/content", auth.Optional(), handlers.GetAllContent)
+	api.Get("/content/slug/:slug", auth.Optional(), handlers.GetContentBySlug)
+	api.Get("/content/:id", auth.Optional(), handlers.GetContent)
+	api.Get("/content/:id/render", auth.Optional(), handlers.RenderContent)
+	api.Get("/content/:id/comments", auth.Optional(), handlers.GetComments)
+
+	// Presence: who has an item open, its edit lock and saves, over a WebSocket. It is
+	// outside the private group since browsers pass the token as a query parameter.
+	api.Get("/content/:id/presence", auth.ProtectedSocket(), auth.RequirePermission("content.read"), handlers.ContentPresenceHandshake, websocket.New(handlers.ContentPresence))
 
 	// User Profiles (Public)
 	api.Get("/users/:username", handlers.GetProfile)
-	api.Get("/users/:username/stories", handlers.GetUserStories)
+	api.Get("/users/:username/stories", auth.Optional(), handlers.GetUserStories)
 
 	// Auth Rate Limiter (5 req/min) - Brute Force Protection
 	authLimiter := limiter.New(limiter.Config{
@@ -125,12 +150,30 @@ func main() {
 	private.Post("/webhooks", auth.RequirePermission("system.settings"), handlers.CreateWebhook)
 	private.Get("/webhooks", auth.RequirePermission("system.settings"), handlers.GetAllWebhooks)
 
+	// Background jobs (Admin)
+	private.Get("/jobs", auth.RequirePermission("system.settings"), handlers.GetJobs)
+	private.Get("/jobs/:id", auth.RequirePermission("system.settings"), handlers.GetJob)
+	private.Post("/jobs/:id/retry", auth.RequirePermission("system.settings"), handlers.RetryJob)
+	private.Post("/jobs/:id/cancel", auth.RequirePermission("system.settings"), handlers.CancelJob)
+
+	// Content Types
+	private.Post("/content-types", auth.RequirePermission("system.settings"), handlers.CreateContentType)
+	private.Put("/content-types/:id", auth.RequirePermission("system.settings"), handlers.UpdateContentType)
+	private.Delete("/content-types/:id", auth.RequirePermission("system.settings"), handlers.DeleteContentType)
+
+	// Block Types
+	private.Post("/block-types", auth.RequirePermission("system.settings"), handlers.CreateBlockType)
+	private.Put("/block-types/:id", auth.RequirePermission("system.settings"), handlers.UpdateBlockType)
+	private.Delete("/block-types/:id", auth.RequirePermission("system.settings"), handlers.DeleteBlockType)
+
 	// Media
 	private.Post("/media", uploadLimiter, auth.RequirePermission("content.create"), handlers.UploadMedia)
 
 	// Content
 	private.Post("/content", auth.RequirePermission("content.create"), handlers.CreateContent)
+	private.Post("/content/bulk", auth.RequirePermission("content.update"), handlers.BulkContent)
 	private.Post("/content/:id/localize", auth.RequirePermission("content.create"), handlers.AddTranslation)
+	private.Post("/content/:id/preview-token", auth.RequirePermission("content.update"), handlers.CreatePreviewToken)
 	private.Delete("/content/:id", auth.RequirePermission("content.delete"), handlers.DeleteContent)
 
 	// Private Update
@@ -138,8 +181,27 @@ func main() {
 
 	// History / Versioning
 	private.Get("/content/:id/history", auth.RequirePermission("content.read"), handlers.GetHistory) // Or some other perm? content.read is redundant. Let's use content.update for history access or keep it simple.
+	private.Get("/content/:id/diff", auth.RequirePermission("content.read"), handlers.GetContentDiff)
 	private.Post("/content/:id/revert/:version", auth.RequirePermission("content.update"), handlers.RevertContent)
 
+	// Drafts: edits to published content wait here until published
+	private.Post("/content/:id/draft/publish", auth.RequirePermission("content.publish"), handlers.PublishDraft)
+	private.Delete("/content/:id/draft", auth.RequirePermission("content.update"), handlers.DiscardDraft)
+
+	// Workflow: the permission for a status change depends on the transition taken
+	private.Post("/content/:id/transition", handlers.TransitionContent)
+	private.Get("/content/:id/transitions", auth.RequirePermission("content.read"), handlers.GetContentTransitions)
+	private.Get("/workflow/transitions", handlers.GetWorkflowTransitions)
+	private.Post("/workflow/transitions", auth.RequirePermission("system.settings"), handlers.CreateWorkflowTransition)
+	private.Put("/workflow/transitions/:id", auth.RequirePermission("system.settings"), handlers.UpdateWorkflowTransition)
+	private.Delete("/workflow/transitions/:id", auth.RequirePermission("system.settings"), handlers.DeleteWorkflowTransition)
+
+	// Reviews: assigned reviewers decide with content.approve or content.review (checked per decision)
+	private.Post("/content/:id/reviews", auth.RequirePermission("content.update"), handlers.RequestReview)
+	private.Get("/content/:id/reviews", auth.RequirePermission("content.read"), handlers.GetContentReviews)
+	private.Get("/reviews/assigned", handlers.GetAssignedReviews)
+	private.Post("/reviews/:id/decision", handlers.DecideReview)
+
 	// Interaction (Comments & Likes)
 	private.Post("/content/:id/comments", auth.RequirePermission("comment.create"), handlers.AddComment)
 	private.Post("/content/:id/like", handlers.ToggleLike) // Likes are usually free for all auth users, no specific perm needed? Or create a 'like.create' perm?
@@ -149,13 +211,9 @@ func main() {
 	// Interaction service might need updating for standard users.
 	// Let's protect comments with 'comment.create' which usually all logged in users have.
 
-	// 5. Start Scheduler (Background Job)
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
-		for range ticker.C {
-			services.PublishScheduledContent()
-		}
-	}()
+	// 5. Start background jobs (scheduled publishing, webhook delivery)
+	services.RegisterJobs()
+	queue.Start(context.Background())
 
 	// 6. Start Server
 	log.Fatal(app.Listen(":3000"))
@@ -27,7 +27,8 @@ func Connect() {
 		DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	} else {
 		log.Println("Connecting to SQLite...")
-		DB, err = gorm.Open(sqlite.Open("content_flow.db"), &gorm.Config{})
+		// Queue workers write concurrently with requests; wait for locks instead of failing
+		DB, err = gorm.Open(sqlite.Open("content_flow.db?_pragma=busy_timeout(5000)"), &gorm.Config{})
 	}
 
 	if err != nil {
@@ -36,3 +37,9 @@ func Connect() {
 
 	log.Println("Connected to Database")
 }
+
+// IsPostgres reports whether Connect selected the PostgreSQL driver; SQLite is the default.
+// Used where JSON and full-text SQL differs between the two.
+func IsPostgres() bool {
+	return DB != nil && DB.Dialector.Name() == "postgres"
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TryLock runs fn while holding the named lock shared by every server instance and
+// reports whether the lock was acquired; when another instance holds it, fn is not run.
+//   - PostgreSQL: a session advisory lock, released when fn returns or the session dies
+//   - SQLite: a row in the locks table leased for ttl and renewed while fn runs, so a
+//     crashed holder only blocks others until the lease runs out
+func TryLock(name string, ttl time.Duration, fn func() error) (bool, error) {
+	if IsPostgres() {
+		return tryAdvisoryLock(name, fn)
+	}
+	return tryLeaseLock(name, ttl, fn)
+}
+
+func tryAdvisoryLock(name string, fn func() error) (bool, error) {
+	acquired := false
+	// Advisory locks belong to a session, so lock and unlock on the same connection
+	err := DB.Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(hashtext(?))", name).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", name)
+		return fn()
+	})
+	return acquired, err
+}
+
+func tryLeaseLock(name string, ttl time.Duration, fn func() error) (bool, error) {
+	owner := uuid.New().String()
+	now := time.Now()
+	// Take the row if it is free or its lease expired
+	result := DB.Exec(`INSERT INTO locks (name, owner, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+		WHERE locks.expires_at < ?`, name, owner, now.Add(ttl), now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	defer DB.Exec("DELETE FROM locks WHERE name = ? AND owner = ?", name, owner)
+
+	done := make(chan struct{})
+	defer close(done)
+	go renewLease(name, owner, ttl, done)
+	return true, fn()
+}
+
+// renewLease extends the lease every third of ttl until done is closed, so a holder
+// that runs longer than ttl keeps the lock
+func renewLease(name, owner string, ttl time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			result := DB.Exec("UPDATE locks SET expires_at = ? WHERE name = ? AND owner = ?", time.Now().Add(ttl), name, owner)
+			if result.Error != nil {
+				log.Printf("Failed to renew lock %s: %v", name, result.Error)
+			} else if result.RowsAffected == 0 {
+				log.Printf("Lost lock %s: its lease ran out before it was renewed", name)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package gql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/visitor"
+)
+
+// Limits on a single operation. A field costs 1 plus the cost of its selection, which
+// counts listCost times under a list, so complexity approximates how many objects an
+// operation can resolve. Introspection is not counted.
+const (
+	maxQueryDepth      = 10
+	maxQueryComplexity = 5000
+	listCost           = 10
+)
+
+// validationRules are the spec's rules plus queryLimitsRule
+var validationRules = append(append([]graphql.ValidationRuleFn{}, graphql.SpecifiedRules...), queryLimitsRule)
+
+// queryLimitsRule rejects operations nested deeper than maxQueryDepth or more complex
+// than maxQueryComplexity before anything is resolved
+func queryLimitsRule(context *graphql.ValidationContext) *graphql.ValidationRuleInstance {
+	return &graphql.ValidationRuleInstance{
+		VisitorOpts: &visitor.VisitorOptions{
+			KindFuncMap: map[string]visitor.NamedVisitFuncs{
+				kinds.OperationDefinition: {
+					Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+						op, ok := p.Node.(*ast.OperationDefinition)
+						if !ok {
+							return visitor.ActionNoChange, nil
+						}
+						var root graphql.Type = context.Schema().QueryType()
+						if op.Operation == ast.OperationTypeMutation {
+							root = context.Schema().MutationType()
+						}
+
+						m := &measure{context: context, visiting: map[string]bool{}, fragments: map[string]cost{}}
+						complexity, depth := m.selections(op.SelectionSet, root)
+						if depth > maxQueryDepth {
+							context.ReportError(limitError(fmt.Sprintf("Query is nested %d levels deep, the limit is %d", depth, maxQueryDepth), op))
+						}
+						if complexity > maxQueryComplexity {
+							context.ReportError(limitError(fmt.Sprintf("Query complexity is %d, the limit is %d", complexity, maxQueryComplexity), op))
+						}
+						return visitor.ActionSkip, nil
+					},
+				},
+			},
+		},
+	}
+}
+
+func limitError(message string, node ast.Node) error {
+	return gqlerrors.NewError(message, []ast.Node{node}, "", nil, []int{}, nil)
+}
+
+// measure walks an operation with its fragments inlined
+type measure struct {
+	context   *graphql.ValidationContext
+	visiting  map[string]bool // Fragments on the current path; cycles are reported by NoFragmentCycles
+	fragments map[string]cost // Measured fragments by name and parent type, so each is walked once
+}
+
+type cost struct {
+	complexity, depth int
+}
+
+// selections returns the complexity and depth of set, selected on parent
+func (m *measure) selections(set *ast.SelectionSet, parent graphql.Type) (complexity, depth int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		var c, d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			c, d = m.field(sel, parent)
+		case *ast.InlineFragment:
+			c, d = m.selections(sel.SelectionSet, m.typeCondition(sel.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			name := sel.Name.Value
+			fragment := m.context.Fragment(name)
+			if fragment == nil || m.visiting[name] {
+				continue
+			}
+			key := name
+			if parent != nil {
+				key += " on " + parent.Name()
+			}
+			measured, ok := m.fragments[key]
+			if !ok {
+				m.visiting[name] = true
+				measured.complexity, measured.depth = m.selections(fragment.SelectionSet, m.typeCondition(fragment.TypeCondition, parent))
+				delete(m.visiting, name)
+				m.fragments[key] = measured
+			}
+			c, d = measured.complexity, measured.depth
+		}
+		// Capped past the limit so repeated spreads cannot overflow
+		complexity = min(complexity+c, maxQueryComplexity+1)
+		depth = max(depth, d)
+	}
+	return complexity, depth
+}
+
+func (m *measure) field(f *ast.Field, parent graphql.Type) (complexity, depth int) {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return 0, 0
+	}
+	var fieldType graphql.Type
+	if object, ok := parent.(*graphql.Object); ok {
+		if def, ok := object.Fields()[f.Name.Value]; ok {
+			fieldType = def.Type
+		}
+	}
+
+	multiplier := 1
+	for {
+		if list, ok := fieldType.(*graphql.List); ok {
+			multiplier *= listCost
+			fieldType = list.OfType
+		} else if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType
+		} else {
+			break
+		}
+	}
+
+	c, d := m.selections(f.SelectionSet, fieldType)
+	return min(1+c*multiplier, maxQueryComplexity+1), 1 + d
+}
+
+func (m *measure) typeCondition(named *ast.Named, parent graphql.Type) graphql.Type {
+	if named == nil {
+		return parent
+	}
+	return m.context.Schema().Type(named.Name.Value)
+}
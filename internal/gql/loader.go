@@ -0,0 +1,128 @@
+package gql
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/services"
+	"encoding/json"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+)
+
+// batch collects the keys the resolvers of one query level ask for and loads them with a
+// single query when the first result is needed. Resolvers return the thunk from get;
+// graphql-go resolves every field of a level before it calls the thunks.
+type batch[K comparable, V any] struct {
+	mu      sync.Mutex
+	load    func(keys []K) (map[K]V, error)
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+func newBatch[K comparable, V any](load func(keys []K) (map[K]V, error)) *batch[K, V] {
+	return &batch[K, V]{load: load, queued: map[K]bool{}, values: map[K]V{}, errs: map[K]error{}}
+}
+
+// get queues key and returns a thunk resolving to its value, or null when load left it out
+func (b *batch[K, V]) get(key K) func() (interface{}, error) {
+	b.mu.Lock()
+	if !b.queued[key] {
+		b.queued[key] = true
+		b.pending = append(b.pending, key)
+	}
+	b.mu.Unlock()
+
+	return func() (interface{}, error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if len(b.pending) > 0 {
+			keys := b.pending
+			b.pending = nil
+			values, err := b.load(keys)
+			for _, k := range keys {
+				if err != nil {
+					b.errs[k] = err
+				} else if v, ok := values[k]; ok {
+					b.values[k] = v
+				}
+			}
+		}
+		if err := b.errs[key]; err != nil {
+			return nil, err
+		}
+		if v, ok := b.values[key]; ok {
+			return v, nil
+		}
+		return nil, nil
+	}
+}
+
+// loaders batch the nested Content fields of one request, so listing content with its
+// authors, comments, translations and references takes a query per field and level
+// instead of one per item
+type loaders struct {
+	viewer       services.Viewer
+	authors      *batch[uint, *models.User]
+	comments     *batch[uint, []models.Comment]
+	likeCounts   *batch[uint, int64]
+	translations *batch[*models.Content, []models.Content]
+
+	mu         sync.Mutex
+	references map[string]*batch[*models.Content, interface{}] // By include
+}
+
+func newLoaders(viewer services.Viewer) *loaders {
+	return &loaders{
+		viewer:     viewer,
+		authors:    newBatch(services.GetUsersByIDs),
+		comments:   newBatch(services.GetCommentsFor),
+		likeCounts: newBatch(services.GetLikeCounts),
+		translations: newBatch(func(contents []*models.Content) (map[*models.Content][]models.Content, error) {
+			byID, err := services.GetTranslationsFor(contents, viewer)
+			if err != nil {
+				return nil, err
+			}
+			out := make(map[*models.Content][]models.Content, len(contents))
+			for _, c := range contents {
+				out[c] = byID[c.ID]
+			}
+			return out, nil
+		}),
+		references: map[string]*batch[*models.Content, interface{}]{},
+	}
+}
+
+// referencesBatch returns the batch expanding references with include
+func (l *loaders) referencesBatch(include string) *batch[*models.Content, interface{}] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.references[include]; ok {
+		return b
+	}
+	b := newBatch(func(contents []*models.Content) (map[*models.Content]interface{}, error) {
+		if err := services.LoadReferences(contents, include, l.viewer); err != nil {
+			return nil, wrapError(err)
+		}
+		out := make(map[*models.Content]interface{}, len(contents))
+		for _, c := range contents {
+			// Round-trip so expanded content is serialized with its REST (JSON) field names
+			raw, err := json.Marshal(c.References)
+			if err != nil {
+				return nil, err
+			}
+			out[c] = decodeJSON(raw)
+		}
+		return out, nil
+	})
+	l.references[include] = b
+	return b
+}
+
+func currentLoaders(p graphql.ResolveParams) *loaders {
+	if l, ok := p.Context.Value(loadersKey).(*loaders); ok {
+		return l
+	}
+	return newLoaders(currentViewer(p))
+}
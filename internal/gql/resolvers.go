@@ -0,0 +1,578 @@
+package gql
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/pagination"
+	"content-flow/internal/pkgs/renderer"
+	"content-flow/internal/pkgs/validator"
+	"content-flow/internal/services"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+type contextKey string
+
+const (
+	userKey    contextKey = "user_id"
+	viewerKey  contextKey = "viewer"
+	loadersKey contextKey = "loaders"
+)
+
+// fieldErrors exposes service validation errors under extensions.errors, in the same
+// shape the REST API uses
+type fieldErrors struct {
+	*validator.ValidationError
+}
+
+func (e fieldErrors) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   "VALIDATION_FAILED",
+		"errors": e.Errors,
+	}
+}
+
+// versionConflict reports a write based on an outdated version, with the current version
+// tag to reload and retry from
+type versionConflict struct {
+	*services.VersionConflictError
+}
+
+func (e versionConflict) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":           "VERSION_CONFLICT",
+		"currentVersion": e.Current,
+	}
+}
+
+func wrapError(err error) error {
+	var vErr *validator.ValidationError
+	if errors.As(err, &vErr) {
+		return fieldErrors{vErr}
+	}
+	var conflict *services.VersionConflictError
+	if errors.As(err, &conflict) {
+		return versionConflict{conflict}
+	}
+	return err
+}
+
+func toFormattedErrors(err error) []gqlerrors.FormattedError {
+	return []gqlerrors.FormattedError{gqlerrors.FormatError(err)}
+}
+
+func currentUser(p graphql.ResolveParams) uint {
+	id, _ := p.Context.Value(userKey).(uint)
+	return id
+}
+
+// currentViewer decides which content the request may see, see services.Viewer
+func currentViewer(p graphql.ResolveParams) services.Viewer {
+	viewer, _ := p.Context.Value(viewerKey).(services.Viewer)
+	return viewer
+}
+
+// requirePermission applies the same rules as auth.RequirePermission to a resolver
+func requirePermission(p graphql.ResolveParams, slug string) (uint, error) {
+	userID := currentUser(p)
+	if userID == 0 {
+		return 0, errors.New("Unauthorized")
+	}
+	ok, err := auth.HasPermission(userID, slug)
+	if err != nil {
+		return 0, errors.New("User not found")
+	}
+	if !ok {
+		return 0, errors.New("Forbidden: Missing permission " + slug)
+	}
+	return userID, nil
+}
+
+func sourceContent(p graphql.ResolveParams) *models.Content {
+	switch c := p.Source.(type) {
+	case *models.Content:
+		return c
+	case models.Content:
+		return &c
+	}
+	return &models.Content{}
+}
+
+// --- Queries ---
+
+func resolveContent(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(int)
+	content, err := services.GetVisibleContent(uint(id), currentViewer(p), nil)
+	if err != nil {
+		return nil, nil
+	}
+	return content, nil
+}
+
+func resolveContentBySlug(p graphql.ResolveParams) (interface{}, error) {
+	slug, _ := p.Args["slug"].(string)
+	language, _ := p.Args["language"].(string)
+	viewer := currentViewer(p)
+	content, moved, err := services.GetContentBySlug(slug, language, viewer, nil)
+	if err != nil {
+		return nil, nil
+	}
+	if moved {
+		if content, err = services.GetVisibleContent(content.ID, viewer, nil); err != nil {
+			return nil, nil
+		}
+	}
+	return content, nil
+}
+
+func resolveContents(contentType string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		filter := services.ContentFilter{Type: contentType, Viewer: currentViewer(p)}
+		filter.Search, _ = p.Args["search"].(string)
+		filter.Status, _ = p.Args["status"].(string)
+		filter.Language, _ = p.Args["language"].(string)
+		filter.Page, _ = p.Args["page"].(int)
+		filter.Limit, _ = p.Args["limit"].(int)
+		if after, ok := p.Args["after"].(string); ok {
+			cursor, err := pagination.Decode(after)
+			if err != nil {
+				return nil, err
+			}
+			filter.Keyset, filter.Cursor = true, cursor
+		}
+		if t, ok := p.Args["type"].(string); ok && contentType == "" {
+			filter.Type = t
+		}
+		if tags, ok := p.Args["tags"].([]interface{}); ok {
+			for _, t := range tags {
+				if s, ok := t.(string); ok {
+					filter.Tags = append(filter.Tags, s)
+				}
+			}
+		}
+		if attrs, ok := p.Args["attributes"].([]interface{}); ok {
+			for _, a := range attrs {
+				m, _ := a.(map[string]interface{})
+				f := services.AttributeFilter{}
+				f.Path, _ = m["path"].(string)
+				f.Op, _ = m["op"].(string)
+				f.Value, _ = m["value"].(string)
+				filter.Attributes = append(filter.Attributes, f)
+			}
+		}
+		if sort, ok := p.Args["sort"].(string); ok {
+			fields, err := services.ParseSort(sort)
+			if err != nil {
+				return nil, wrapError(err)
+			}
+			filter.Sort = fields
+		}
+
+		contents, info, err := services.GetAllContent(filter)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		items := make([]*models.Content, len(contents))
+		for i := range contents {
+			items[i] = &contents[i]
+		}
+
+		page, limit := filter.Page, filter.Limit
+		if page <= 0 {
+			page = 1
+		}
+		if limit <= 0 {
+			limit = 10
+		}
+		if limit > pagination.MaxLimit {
+			limit = pagination.MaxLimit
+		}
+		result := map[string]interface{}{
+			"items": items,
+			"total": info.Total,
+			"page":  page,
+			"limit": limit,
+		}
+		if filter.Keyset {
+			result["nextCursor"] = info.NextCursor
+			if info.NextCursor == "" {
+				result["nextCursor"] = nil
+			}
+		}
+		return result, nil
+	}
+}
+
+func resolveUser(p graphql.ResolveParams) (interface{}, error) {
+	username, _ := p.Args["username"].(string)
+	user, err := services.GetUserByUsername(username)
+	if err != nil {
+		return nil, nil
+	}
+	return user, nil
+}
+
+func resolveUserStories(p graphql.ResolveParams) (interface{}, error) {
+	user, ok := p.Source.(*models.User)
+	if !ok {
+		return nil, nil
+	}
+	return services.GetUserStories(user.ID, currentViewer(p), nil)
+}
+
+func resolveAuthor(p graphql.ResolveParams) (interface{}, error) {
+	content := sourceContent(p)
+	if content.AuthorID == 0 {
+		return nil, nil
+	}
+	return currentLoaders(p).authors.get(content.AuthorID), nil
+}
+
+func resolveTranslations(p graphql.ResolveParams) (interface{}, error) {
+	return currentLoaders(p).translations.get(sourceContent(p)), nil
+}
+
+func resolveComments(p graphql.ResolveParams) (interface{}, error) {
+	return currentLoaders(p).comments.get(sourceContent(p).ID), nil
+}
+
+func resolveLikeCount(p graphql.ResolveParams) (interface{}, error) {
+	return currentLoaders(p).likeCounts.get(sourceContent(p).ID), nil
+}
+
+func resolveRendered(p graphql.ResolveParams) (interface{}, error) {
+	f, _ := p.Args["format"].(string)
+	format, err := renderer.ParseFormat(f)
+	if err != nil {
+		return nil, err
+	}
+	return services.RenderContent(sourceContent(p), format)
+}
+
+func resolveReferences(p graphql.ResolveParams) (interface{}, error) {
+	include, _ := p.Args["include"].(string)
+	return currentLoaders(p).referencesBatch(include).get(sourceContent(p)), nil
+}
+
+// --- Mutations ---
+
+func (b *builder) mutation() *graphql.Object {
+	contentInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ContentInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"slug":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"body":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"type":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"attributes":  &graphql.InputObjectFieldConfig{Type: jsonScalar},
+			"blocks":      &graphql.InputObjectFieldConfig{Type: jsonScalar},
+			"status":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"language":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"categoryIds": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.Int)},
+			"tags":        &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)},
+			"publishedAt": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"unpublishAt": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+			"references":  &graphql.InputObjectFieldConfig{Type: jsonScalar},
+			"changeMessage": &graphql.InputObjectFieldConfig{
+				Type:        graphql.String,
+				Description: "Why the change was made, kept in the version history",
+			},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createContent": &graphql.Field{
+				Type: b.content,
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(contentInput)},
+				},
+				Resolve: resolveCreateContent,
+			},
+			"updateContent": &graphql.Field{
+				Type: b.content,
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(contentInput)},
+					"expectedVersion": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Version tag the edit is based on, e.g. \"5-d2\"; fails with VERSION_CONFLICT when the content changed since",
+					},
+				},
+				Resolve: resolveUpdateContent,
+			},
+			"deleteContent": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"expectedVersion": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Version tag the deletion is based on; fails with VERSION_CONFLICT when the content changed since",
+					},
+				},
+				Resolve: resolveDeleteContent,
+			},
+			"transitionContent": &graphql.Field{
+				Type:        b.content,
+				Description: "Moves content to another workflow status; the transition's permission is required",
+				Args: graphql.FieldConfigArgument{
+					"id":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"status":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"comment":     &graphql.ArgumentConfig{Type: graphql.String},
+					"publishedAt": &graphql.ArgumentConfig{Type: graphql.DateTime},
+				},
+				Resolve: resolveTransitionContent,
+			},
+			"addComment": &graphql.Field{
+				Type: b.comment,
+				Args: graphql.FieldConfigArgument{
+					"contentId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"body":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolveAddComment,
+			},
+			"toggleLike": &graphql.Field{
+				Type:        graphql.Boolean,
+				Description: "Returns true when the content is now liked",
+				Args: graphql.FieldConfigArgument{
+					"contentId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: resolveToggleLike,
+			},
+		},
+	})
+}
+
+// contentInput is the decoded ContentInput argument
+type contentInput struct {
+	Title         string
+	Slug          string
+	Body          string
+	Type          string
+	Attributes    string
+	Blocks        json.RawMessage
+	Status        string
+	Language      string
+	CategoryIDs   []uint
+	Tags          []string
+	PublishedAt   *time.Time
+	UnpublishAt   *time.Time
+	References    map[string][]uint
+	ChangeMessage string
+}
+
+func decodeInput(p graphql.ResolveParams) (*contentInput, error) {
+	args, _ := p.Args["input"].(map[string]interface{})
+	in := &contentInput{}
+	in.Title, _ = args["title"].(string)
+	in.Slug, _ = args["slug"].(string)
+	in.Body, _ = args["body"].(string)
+	in.Type, _ = args["type"].(string)
+	in.Status, _ = args["status"].(string)
+	in.Language, _ = args["language"].(string)
+	in.ChangeMessage, _ = args["changeMessage"].(string)
+
+	attributes, err := attributesJSON(args["attributes"])
+	if err != nil {
+		return nil, err
+	}
+	in.Attributes = attributes
+
+	if v, ok := args["blocks"]; ok && v != nil {
+		if in.Blocks, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := args["references"]; ok && v != nil {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &in.References); err != nil {
+			return nil, errors.New("references must map field names to lists of IDs")
+		}
+	}
+	if ids, ok := args["categoryIds"].([]interface{}); ok {
+		for _, id := range ids {
+			if n, ok := id.(int); ok {
+				in.CategoryIDs = append(in.CategoryIDs, uint(n))
+			}
+		}
+	}
+	if tags, ok := args["tags"].([]interface{}); ok {
+		for _, t := range tags {
+			if s, ok := t.(string); ok {
+				in.Tags = append(in.Tags, s)
+			}
+		}
+	}
+	if t, ok := args["publishedAt"].(time.Time); ok {
+		in.PublishedAt = &t
+	}
+	if t, ok := args["unpublishAt"].(time.Time); ok {
+		in.UnpublishAt = &t
+	}
+	return in, nil
+}
+
+func resolveCreateContent(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requirePermission(p, "content.create")
+	if err != nil {
+		return nil, err
+	}
+	in, err := decodeInput(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.ContentCreateRequest{
+		Title:         in.Title,
+		Slug:          in.Slug,
+		Body:          in.Body,
+		Blocks:        in.Blocks,
+		Type:          in.Type,
+		Attributes:    in.Attributes,
+		Status:        in.Status,
+		Language:      in.Language,
+		CategoryIDs:   in.CategoryIDs,
+		Tags:          in.Tags,
+		PublishedAt:   in.PublishedAt,
+		UnpublishAt:   in.UnpublishAt,
+		References:    in.References,
+		ChangeMessage: in.ChangeMessage,
+	}
+	if errs := validator.ValidateStruct(&req); len(errs) > 0 {
+		return nil, wrapError(validator.NewValidationError(errs))
+	}
+
+	content := &models.Content{
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Body:          req.Body,
+		Type:          req.Type,
+		Attributes:    req.Attributes,
+		Status:        req.Status,
+		Language:      req.Language,
+		UnpublishAt:   req.UnpublishAt,
+		ChangeMessage: req.ChangeMessage,
+	}
+	if err := services.CreateContent(content, req.CategoryIDs, req.Tags, req.PublishedAt, req.Blocks, req.References, userID); err != nil {
+		return nil, wrapError(err)
+	}
+	return content, nil
+}
+
+func resolveUpdateContent(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requirePermission(p, "content.update")
+	if err != nil {
+		return nil, err
+	}
+	id, _ := p.Args["id"].(int)
+	in, err := decodeInput(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.ContentUpdateRequest{
+		Title:         in.Title,
+		Slug:          in.Slug,
+		Body:          in.Body,
+		Blocks:        in.Blocks,
+		Type:          in.Type,
+		Attributes:    in.Attributes,
+		Status:        in.Status,
+		Language:      in.Language,
+		CategoryIDs:   in.CategoryIDs,
+		Tags:          in.Tags,
+		PublishedAt:   in.PublishedAt,
+		UnpublishAt:   in.UnpublishAt,
+		References:    in.References,
+		ChangeMessage: in.ChangeMessage,
+	}
+	if errs := validator.ValidateStruct(&req); len(errs) > 0 {
+		return nil, wrapError(validator.NewValidationError(errs))
+	}
+
+	expectedVersion, _ := p.Args["expectedVersion"].(string)
+	content, err := services.UpdateContent(uint(id), req.Title, req.Slug, req.Body, req.Type, req.Attributes, req.Status, req.Language, req.CategoryIDs, req.Tags, req.PublishedAt, req.UnpublishAt, req.Blocks, req.References, req.ChangeMessage, expectedVersion, userID)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return content, nil
+}
+
+func resolveTransitionContent(p graphql.ResolveParams) (interface{}, error) {
+	userID := currentUser(p)
+	if userID == 0 {
+		return nil, errors.New("Unauthorized")
+	}
+	id, _ := p.Args["id"].(int)
+	status, _ := p.Args["status"].(string)
+	comment, _ := p.Args["comment"].(string)
+	var publishedAt *time.Time
+	if t, ok := p.Args["publishedAt"].(time.Time); ok {
+		publishedAt = &t
+	}
+	content, err := services.TransitionContent(uint(id), status, comment, publishedAt, userID)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return content, nil
+}
+
+func resolveDeleteContent(p graphql.ResolveParams) (interface{}, error) {
+	if _, err := requirePermission(p, "content.delete"); err != nil {
+		return nil, err
+	}
+	id, _ := p.Args["id"].(int)
+	expectedVersion, _ := p.Args["expectedVersion"].(string)
+	if err := services.DeleteContent(uint(id), expectedVersion); err != nil {
+		return nil, wrapError(err)
+	}
+	return true, nil
+}
+
+func resolveAddComment(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := requirePermission(p, "comment.create")
+	if err != nil {
+		return nil, err
+	}
+	contentID, _ := p.Args["contentId"].(int)
+	body, _ := p.Args["body"].(string)
+	if body == "" {
+		return nil, errors.New("Comment body cannot be empty")
+	}
+	if err := requireVisible(p, uint(contentID)); err != nil {
+		return nil, err
+	}
+	return services.AddComment(userID, uint(contentID), body)
+}
+
+func resolveToggleLike(p graphql.ResolveParams) (interface{}, error) {
+	userID := currentUser(p)
+	if userID == 0 {
+		return nil, errors.New("Unauthorized")
+	}
+	contentID, _ := p.Args["contentId"].(int)
+	if err := requireVisible(p, uint(contentID)); err != nil {
+		return nil, err
+	}
+	return services.ToggleLike(userID, uint(contentID))
+}
+
+// requireVisible rejects mutations on content the caller cannot see
+func requireVisible(p graphql.ResolveParams, contentID uint) error {
+	visible, err := services.CanView(contentID, currentViewer(p))
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return errors.New("Content not found")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package gql
+
+import (
+	"encoding/json"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// jsonScalar passes arbitrary JSON (attributes, blocks, references) through unchanged
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Arbitrary JSON value",
+	Serialize: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case json.RawMessage:
+			return decodeJSON(v)
+		case []byte:
+			return decodeJSON(v)
+		}
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: parseLiteral,
+})
+
+func parseLiteral(valueAST ast.Value) interface{} {
+	switch v := valueAST.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		var n json.Number = json.Number(v.Value)
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		return v.Value
+	case *ast.FloatValue:
+		var n json.Number = json.Number(v.Value)
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+		return v.Value
+	case *ast.ObjectValue:
+		obj := map[string]interface{}{}
+		for _, f := range v.Fields {
+			obj[f.Name.Value] = parseLiteral(f.Value)
+		}
+		return obj
+	case *ast.ListValue:
+		list := make([]interface{}, len(v.Values))
+		for i, item := range v.Values {
+			list[i] = parseLiteral(item)
+		}
+		return list
+	}
+	return nil
+}
+
+func decodeJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,477 @@
+package gql
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/jsonschema"
+	"content-flow/internal/services"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+var (
+	mu        sync.Mutex
+	cached    *graphql.Schema
+	cachedSig string
+)
+
+// Execute runs a GraphQL request against the schema derived from the models and the
+// registered content types. userID is 0 for anonymous callers.
+func Execute(ctx context.Context, userID uint, query string, variables map[string]interface{}, operationName string) *graphql.Result {
+	schema, err := currentSchema()
+	if err != nil {
+		return &graphql.Result{Errors: toFormattedErrors(err)}
+	}
+
+	// Resolved once per request; a token for a deleted user reads like an anonymous visitor
+	viewer, err := services.ViewerFor(userID)
+	if err != nil {
+		viewer = services.Viewer{}
+	}
+	ctx = context.WithValue(ctx, userKey, userID)
+	ctx = context.WithValue(ctx, viewerKey, viewer)
+	ctx = context.WithValue(ctx, loadersKey, newLoaders(viewer))
+
+	// graphql.Do with queryLimitsRule added to the validation rules
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	// Fragment cycles first: OverlappingFieldsCanBeMerged recurses through them forever
+	for _, rules := range [][]graphql.ValidationRuleFn{{graphql.NoFragmentCyclesRule}, validationRules} {
+		if result := graphql.ValidateDocument(schema, doc, rules); !result.IsValid {
+			return &graphql.Result{Errors: result.Errors}
+		}
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        *schema,
+		AST:           doc,
+		Args:          variables,
+		OperationName: operationName,
+		Context:       ctx,
+	})
+}
+
+// currentSchema rebuilds the schema whenever the content type registry changed
+func currentSchema() (*graphql.Schema, error) {
+	contentTypes, err := services.GetAllContentTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var sig strings.Builder
+	for _, ct := range contentTypes {
+		sig.WriteString(ct.Name + "@" + ct.UpdatedAt.String() + ";")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cached != nil && cachedSig == sig.String() {
+		return cached, nil
+	}
+
+	schema, err := buildSchema(contentTypes)
+	if err != nil {
+		return nil, err
+	}
+	cached, cachedSig = schema, sig.String()
+	return cached, nil
+}
+
+type builder struct {
+	content    *graphql.Object
+	user       *graphql.Object
+	category   *graphql.Object
+	tag        *graphql.Object
+	comment    *graphql.Object
+	media      *graphql.Object
+	connection *graphql.Object
+	typeNames  map[string]bool
+}
+
+func buildSchema(contentTypes []models.ContentType) (*graphql.Schema, error) {
+	b := &builder{typeNames: map[string]bool{}}
+
+	b.category = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Category",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.Int},
+			"name":        &graphql.Field{Type: graphql.String},
+			"slug":        &graphql.Field{Type: graphql.String},
+			"description": &graphql.Field{Type: graphql.String},
+		},
+	})
+	b.tag = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.Int},
+			"name": &graphql.Field{Type: graphql.String},
+			"slug": &graphql.Field{Type: graphql.String},
+		},
+	})
+	b.media = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Media",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.Int},
+			"filename":  &graphql.Field{Type: graphql.String},
+			"url":       &graphql.Field{Type: graphql.String},
+			"size":      &graphql.Field{Type: graphql.Int},
+			"createdAt": &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+	b.user = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        &graphql.Field{Type: graphql.Int},
+				"username":  &graphql.Field{Type: graphql.String},
+				"fullName":  &graphql.Field{Type: graphql.String},
+				"bio":       &graphql.Field{Type: graphql.String},
+				"avatar":    &graphql.Field{Type: graphql.String},
+				"createdAt": &graphql.Field{Type: graphql.DateTime},
+				"stories": &graphql.Field{
+					Type:    graphql.NewList(b.content),
+					Resolve: resolveUserStories,
+				},
+			}
+		}),
+	})
+	b.comment = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.Int},
+			"body":      &graphql.Field{Type: graphql.String},
+			"createdAt": &graphql.Field{Type: graphql.DateTime},
+			"user":      &graphql.Field{Type: b.user},
+		},
+	})
+	b.content = graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Content",
+		Fields: graphql.FieldsThunk(func() graphql.Fields { return b.contentFields() }),
+	})
+	b.connection = b.connectionOf("ContentConnection", b.content)
+
+	query := graphql.Fields{
+		"content": &graphql.Field{
+			Type: b.content,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: resolveContent,
+		},
+		"contentBySlug": &graphql.Field{
+			Type:        b.content,
+			Description: "Resolves old slugs to the content's current revision",
+			Args: graphql.FieldConfigArgument{
+				"slug":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"language": &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: resolveContentBySlug,
+		},
+		"contents": &graphql.Field{
+			Type:    b.connection,
+			Args:    filterArgs(true),
+			Resolve: resolveContents(""),
+		},
+		"user": &graphql.Field{
+			Type: b.user,
+			Args: graphql.FieldConfigArgument{
+				"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: resolveUser,
+		},
+		"categories": &graphql.Field{
+			Type: graphql.NewList(b.category),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return services.GetAllCategories()
+			},
+		},
+		"tags": &graphql.Field{
+			Type: graphql.NewList(b.tag),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return services.GetAllTags()
+			},
+		},
+	}
+
+	// One typed query per registered content type, e.g. productContents { items { data { price } } }
+	for _, ct := range contentTypes {
+		name := typeName(ct.Name)
+		if name == "" || b.typeNames[name] {
+			continue
+		}
+		b.typeNames[name] = true
+
+		typed := b.typedContent(name, ct)
+		query[lowerFirst(name)+"Contents"] = &graphql.Field{
+			Type:        b.connectionOf(name+"ContentConnection", typed),
+			Description: "Content of type " + ct.Name,
+			Args:        filterArgs(false),
+			Resolve:     resolveContents(ct.Name),
+		}
+	}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:    graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: query}),
+		Mutation: b.mutation(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (b *builder) contentFields() graphql.Fields {
+	return graphql.Fields{
+		"id":            &graphql.Field{Type: graphql.Int},
+		"title":         &graphql.Field{Type: graphql.String},
+		"slug":          &graphql.Field{Type: graphql.String},
+		"body":          &graphql.Field{Type: graphql.String},
+		"type":          &graphql.Field{Type: graphql.String},
+		"status":        &graphql.Field{Type: graphql.String},
+		"language":      &graphql.Field{Type: graphql.String},
+		"groupId":       &graphql.Field{Type: graphql.String},
+		"version":       &graphql.Field{Type: graphql.Int},
+		"authorId":      &graphql.Field{Type: graphql.Int},
+		"updatedById":   &graphql.Field{Type: graphql.Int, Description: "User who made the current version"},
+		"changeMessage": &graphql.Field{Type: graphql.String},
+		"publishedAt":   &graphql.Field{Type: graphql.DateTime},
+		"unpublishAt":   &graphql.Field{Type: graphql.DateTime},
+		"createdAt":     &graphql.Field{Type: graphql.DateTime},
+		"updatedAt":     &graphql.Field{Type: graphql.DateTime},
+		"snippet":       &graphql.Field{Type: graphql.String, Description: "Highlighted match when listing with search"},
+		"attributes": &graphql.Field{
+			Type: jsonScalar,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return decodeJSON([]byte(sourceContent(p).Attributes)), nil
+			},
+		},
+		"blocks": &graphql.Field{
+			Type: jsonScalar,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return decodeJSON(sourceContent(p).Blocks), nil
+			},
+		},
+		"rendered": &graphql.Field{
+			Type: graphql.String,
+			Args: graphql.FieldConfigArgument{
+				"format": &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "html"},
+			},
+			Resolve: resolveRendered,
+		},
+		"references": &graphql.Field{
+			Type:        jsonScalar,
+			Description: "Reference field values; pass include to expand them like ?include= on the REST API",
+			Args: graphql.FieldConfigArgument{
+				"include": &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: resolveReferences,
+		},
+		"categories": &graphql.Field{Type: graphql.NewList(b.category)},
+		"tags":       &graphql.Field{Type: graphql.NewList(b.tag)},
+		"author": &graphql.Field{
+			Type:    b.user,
+			Resolve: resolveAuthor,
+		},
+		"translations": &graphql.Field{
+			Type:    graphql.NewList(b.content),
+			Resolve: resolveTranslations,
+		},
+		"comments": &graphql.Field{
+			Type:    graphql.NewList(b.comment),
+			Resolve: resolveComments,
+		},
+		"likeCount": &graphql.Field{
+			Type:    graphql.Int,
+			Resolve: resolveLikeCount,
+		},
+	}
+}
+
+// typedContent is Content plus a "data" field typed from the content type's JSON Schema
+func (b *builder) typedContent(name string, ct models.ContentType) *graphql.Object {
+	fields := b.contentFields()
+
+	var dataType graphql.Output = jsonScalar
+	if schema, err := jsonschema.Parse(ct.Schema); err == nil && len(ct.Schema) > 0 {
+		dataType = schemaOutput(name+"Attributes", schema)
+	}
+	fields["data"] = &graphql.Field{
+		Type:        dataType,
+		Description: "Attributes typed from the " + ct.Name + " schema",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return decodeJSON([]byte(sourceContent(p).Attributes)), nil
+		},
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        name + "Content",
+		Description: ct.Description,
+		Fields:      fields,
+	})
+}
+
+func (b *builder) connectionOf(name string, item *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"items": &graphql.Field{Type: graphql.NewList(item)},
+			"total": &graphql.Field{Type: graphql.Int},
+			"page":  &graphql.Field{Type: graphql.Int},
+			"limit": &graphql.Field{Type: graphql.Int},
+			"nextCursor": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Only set when paging with after",
+			},
+		},
+	})
+}
+
+// attributeFilterInput mirrors services.AttributeFilter
+var attributeFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "AttributeFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"path":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String), Description: "Dotted path inside attributes, e.g. dimensions.width"},
+		"op":    &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: "eq", Description: "eq, ne, lt, lte, gt, gte, like, in, exists"},
+		"value": &graphql.InputObjectFieldConfig{Type: graphql.String},
+	},
+})
+
+// filterArgs mirrors services.ContentFilter
+func filterArgs(withType bool) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"search":     &graphql.ArgumentConfig{Type: graphql.String},
+		"status":     &graphql.ArgumentConfig{Type: graphql.String},
+		"language":   &graphql.ArgumentConfig{Type: graphql.String},
+		"tags":       &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+		"attributes": &graphql.ArgumentConfig{Type: graphql.NewList(attributeFilterInput)},
+		"sort":       &graphql.ArgumentConfig{Type: graphql.String, Description: "Same syntax as ?sort= on the REST API"},
+		"page":       &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+		"limit":      &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10, Description: "At most 100"},
+		"after":      &graphql.ArgumentConfig{Type: graphql.String, Description: "Keyset pagination: nextCursor of the previous page, \"\" for the first"},
+	}
+	if withType {
+		args["type"] = &graphql.ArgumentConfig{Type: graphql.String}
+	}
+	return args
+}
+
+// schemaOutput maps a JSON Schema onto a GraphQL output type. Objects without declared
+// properties, and anything not representable, fall back to the JSON scalar.
+func schemaOutput(name string, s *jsonschema.Schema) graphql.Output {
+	kind := ""
+	for _, t := range s.Type {
+		if t != "null" {
+			kind = t
+			break
+		}
+	}
+
+	switch kind {
+	case "string":
+		return graphql.String
+	case "integer":
+		return graphql.Int
+	case "number":
+		return graphql.Float
+	case "boolean":
+		return graphql.Boolean
+	case "array":
+		if s.Items == nil {
+			return jsonScalar
+		}
+		return graphql.NewList(schemaOutput(name+"Item", s.Items))
+	case "object", "":
+		if len(s.Properties) == 0 {
+			return jsonScalar
+		}
+		fields := graphql.Fields{}
+		for prop, propSchema := range s.Properties {
+			fieldName := fieldName(prop)
+			if fieldName == "" {
+				continue
+			}
+			key := prop
+			fields[fieldName] = &graphql.Field{
+				Type:        schemaOutput(name+typeName(prop), propSchema),
+				Description: propSchema.Description,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if m, ok := p.Source.(map[string]interface{}); ok {
+						return m[key], nil
+					}
+					return nil, nil
+				},
+			}
+		}
+		if len(fields) == 0 {
+			return jsonScalar
+		}
+		return graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: fields})
+	}
+	return jsonScalar
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// typeName turns "blog post" into "BlogPost"; it returns "" when nothing usable is left
+func typeName(s string) string {
+	var out strings.Builder
+	for _, part := range invalidNameChars.Split(s, -1) {
+		if part == "" {
+			continue
+		}
+		out.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	name := out.String()
+	if name == "" {
+		return ""
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "T" + name
+	}
+	if strings.HasPrefix(name, "__") {
+		return ""
+	}
+	return name
+}
+
+// fieldName keeps snake_case property names as they are, since clients already use them in attributes
+func fieldName(s string) string {
+	name := invalidNameChars.ReplaceAllString(s, "_")
+	if name == "" || strings.HasPrefix(name, "__") {
+		return ""
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// attributesJSON accepts attributes as a JSON string (as in the REST API) or as an object
+func attributesJSON(v interface{}) (string, error) {
+	switch a := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return a, nil
+	}
+	raw, err := json.Marshal(v)
+	return string(raw), err
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/validator"
+	"content-flow/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetBlockDefinitions godoc
+// @Summary List block types
+// @Description Lists built-in and custom block types with the JSON Schema of their data, for building editor UIs
+// @Tags Block Types
+// @Produce json
+// @Success 200 {array} blocks.Definition
+// @Failure 500 {object} apierrors.AppError
+// @Router /api/block-types [get]
+func GetBlockDefinitions(c *fiber.Ctx) error {
+	defs, err := services.GetBlockDefinitions(database.DB)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	return c.JSON(defs)
+}
+
+// CreateBlockType godoc
+// @Summary Register a custom block type
+// @Tags Block Types
+// @Accept json
+// @Produce json
+// @Param blockType body models.BlockTypeRequest true "Block type"
+// @Success 200 {object} models.BlockType
+// @Failure 400 {object} apierrors.AppError
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/block-types [post]
+func CreateBlockType(c *fiber.Ctx) error {
+	req := new(models.BlockTypeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	bt, err := services.CreateBlockType(req.Type, req.Label, req.Description, req.Schema)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to create block type: " + err.Error())
+	}
+
+	return c.JSON(bt)
+}
+
+// UpdateBlockType godoc
+// @Summary Update a custom block type
+// @Tags Block Types
+// @Accept json
+// @Produce json
+// @Param id path int true "Block Type ID"
+// @Param blockType body models.BlockTypeRequest true "Block type"
+// @Success 200 {object} models.BlockType
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/block-types/{id} [put]
+func UpdateBlockType(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.BlockTypeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	if _, err := services.GetBlockTypeByID(uint(id)); err != nil {
+		return apierrors.NotFound("Block type not found")
+	}
+
+	bt, err := services.UpdateBlockType(uint(id), req.Type, req.Label, req.Description, req.Schema)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to update block type: " + err.Error())
+	}
+
+	return c.JSON(bt)
+}
+
+// DeleteBlockType godoc
+// @Summary Delete a custom block type
+// @Description Existing content keeps its blocks but can no longer be saved with blocks of this type
+// @Tags Block Types
+// @Produce json
+// @Param id path int true "Block Type ID"
+// @Success 200 {object} map[string]bool
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/block-types/{id} [delete]
+func DeleteBlockType(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := services.DeleteBlockType(uint(id)); err != nil {
+		return apierrors.Internal("Failed to delete block type: " + err.Error())
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/validator"
+	"content-flow/internal/services"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// BulkContent godoc
+// @Summary Bulk content operations
+// @Description Applies one action to up to 200 items picked by ids or by a filter like GET /api/content: publish, unpublish, schedule (published_at), delete, restore, add_tags/remove_tags (tags), set_categories (category_ids) or change_author (author_id).
+// @Description Each item changes as it would on its own: status changes take workflow transitions, changes are saved as new versions (or to the working draft of content that has one) and fire webhooks.
+// @Description Items are applied one by one and reported with their own code unless atomic is set; then the first failure rolls back every item and its code is the response status.
+// @Tags Content
+// @Accept json
+// @Produce json
+// @Param bulk body models.ContentBulkRequest true "Action and items"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
+// @Failure 409 {object} map[string]interface{}
+// @Security Bearer
+// @Router /api/content/bulk [post]
+func BulkContent(c *fiber.Ctx) error {
+	req := new(models.ContentBulkRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	// Deleting and restoring need content.delete on top of content.update, and publishing
+	// content.publish, since publishing the draft of published content takes no transition
+	// that would check it
+	extra := ""
+	switch req.Action {
+	case models.BulkDelete, models.BulkRestore:
+		extra = "content.delete"
+	case models.BulkPublish:
+		extra = "content.publish"
+	}
+	if extra != "" {
+		allowed, err := auth.HasPermission(auth.UserID(c), extra)
+		if err != nil {
+			return apierrors.Internal(err.Error())
+		}
+		if !allowed {
+			return apierrors.New(fiber.StatusForbidden, "Forbidden: Missing permission "+extra)
+		}
+	}
+
+	result, err := services.BulkContent(req, viewerFrom(c), auth.UserID(c))
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to apply bulk action: " + err.Error())
+	}
+
+	status := fiber.StatusOK
+	items := make([]fiber.Map, len(result.Results))
+	for i, r := range result.Results {
+		var code int
+		items[i], code = bulkItem(r)
+		if req.Atomic && r.Err != nil && !errors.Is(r.Err, services.ErrBulkRolledBack) {
+			status = code
+		}
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success":   result.Failed == 0,
+		"action":    req.Action,
+		"atomic":    req.Atomic,
+		"total":     len(result.Results),
+		"succeeded": result.Succeeded,
+		"failed":    result.Failed,
+		"results":   items,
+	})
+}
+
+// bulkItem reports the outcome of one item with the status and message its single-item
+// endpoint would answer with
+func bulkItem(r services.BulkItemResult) (fiber.Map, int) {
+	item := fiber.Map{"id": r.ID, "success": r.Err == nil}
+	if r.Err == nil {
+		if r.VersionTag != "" {
+			item["version"] = r.VersionTag
+		}
+		return item, fiber.StatusOK
+	}
+
+	var vErr *validator.ValidationError
+	var conflict *services.VersionConflictError
+	code := fiber.StatusInternalServerError
+	switch {
+	case errors.As(r.Err, &vErr):
+		code = fiber.StatusBadRequest
+		item["errors"] = vErr.Errors
+	case errors.As(r.Err, &conflict):
+		code = fiber.StatusConflict
+		item["current_version"] = conflict.Current
+	case errors.Is(r.Err, gorm.ErrRecordNotFound):
+		code = fiber.StatusNotFound
+	case errors.Is(r.Err, services.ErrTransitionForbidden):
+		code = fiber.StatusForbidden
+	case errors.Is(r.Err, services.ErrStatusChanged):
+		code = fiber.StatusConflict
+	case errors.Is(r.Err, services.ErrBulkRolledBack):
+		code = fiber.StatusFailedDependency
+	}
+	item["code"] = code
+	item["message"] = r.Err.Error()
+	if code == fiber.StatusNotFound {
+		item["message"] = "Content not found"
+	}
+	return item, code
+}
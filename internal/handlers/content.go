@@ -3,12 +3,18 @@ package handlers
 import (
 	"content-flow/internal/models"
 	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/renderer"
 	"content-flow/internal/pkgs/validator"
 	"content-flow/internal/services"
+	"errors"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // CreateContent godoc
@@ -20,6 +26,7 @@ import (
 // @Param content body models.ContentCreateRequest true "Content object"
 // @Success 200 {object} models.Content
 // @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
 // @Failure 500 {object} apierrors.AppError
 // @Security Bearer
 // @Router /api/content [post]
@@ -38,18 +45,26 @@ func CreateContent(c *fiber.Ctx) error {
 	}
 
 	content := &models.Content{
-		Title:      req.Title,
-		Slug:       req.Slug,
-		Body:       req.Body,
-		Type:       req.Type,
-		Attributes: req.Attributes,
-		Status:     req.Status,
-		Language:   req.Language,
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Body:          req.Body,
+		Type:          req.Type,
+		Attributes:    req.Attributes,
+		Status:        req.Status,
+		Language:      req.Language,
+		UnpublishAt:   req.UnpublishAt,
+		ChangeMessage: req.ChangeMessage,
 	}
 
 	userID := uint(c.Locals("user_id").(float64))
 
-	if err := services.CreateContent(content, req.CategoryIDs, req.Tags, req.PublishedAt, req.Blocks, userID); err != nil {
+	if err := services.CreateContent(content, req.CategoryIDs, req.Tags, req.PublishedAt, req.Blocks, req.References, userID); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if failed := transitionFailed(err); failed != nil {
+			return failed
+		}
 		return apierrors.Internal("Failed to create content: " + err.Error())
 	}
 
@@ -67,18 +82,46 @@ func CreateContent(c *fiber.Ctx) error {
 // @Param lang query string false "Language code"
 // @Param tags query string false "Comma separated tags"
 // @Param page query int false "Page number (default 1)"
-// @Param limit query int false "Items per page (default 10)"
+// @Param limit query int false "Items per page (default 10, max 100)"
+// @Param cursor query string false "Keyset pagination: next_cursor of the previous page, empty for the first page"
+// @Param pagination query string false "Set to 'cursor' to use keyset pagination"
+// @Param total query bool false "Include the total count (default true for page, false for cursor pagination)"
+// @Param filter[attributes.price][lt] query string false "Attribute filter: filter[attributes.<path>][eq|ne|lt|lte|gt|gte|like|in|exists]=value"
+// @Param sort query string false "Comma separated sort fields, '-' for descending (e.g. -attributes.rating,created_at)"
+// @Param include query string false "Comma separated reference fields to expand, dotted for nesting (e.g. products.manufacturer)"
+// @Param fields query string false "Comma separated fields to return (e.g. id,title,slug,tags)"
+// @Param facets query string false "Comma separated facets to count over the filtered results: type, status, language, tags, categories"
 // @Success 200 {object} models.PaginatedContentResponse
+// @Failure 400 {object} apierrors.AppError
 // @Failure 500 {object} apierrors.AppError
 // @Router /api/content [get]
 func GetAllContent(c *fiber.Ctx) error {
+	params, err := pageParams(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+	fields, err := services.ParseContentFields(c.Query("fields"))
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+	if c.Query("include") != "" {
+		fields.Add("references")
+	}
+	viewer := viewerFrom(c)
 	filter := services.ContentFilter{
-		Search:   c.Query("q"),
-		Type:     c.Query("type"),
-		Status:   c.Query("status"),
-		Language: c.Query("lang"),
-		Page:     c.QueryInt("page", 1),
-		Limit:    c.QueryInt("limit", 10),
+		Viewer:    viewer,
+		Search:    c.Query("q"),
+		Type:      c.Query("type"),
+		Status:    c.Query("status"),
+		Language:  c.Query("lang"),
+		Fields:    fields,
+		Page:      params.Page,
+		Limit:     params.Limit,
+		Keyset:    params.Keyset,
+		Cursor:    params.Cursor,
+		SkipTotal: params.SkipTotal,
 	}
 
 	if tags := c.Query("tags"); tags != "" {
@@ -107,19 +150,61 @@ func GetAllContent(c *fiber.Ctx) error {
 		filter.Tags = strings.Split(tagsStr, ",")
 	}
 
-	contents, total, err := services.GetAllContent(filter)
+	var filterErr error
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		f, ok, err := services.ParseAttributeFilter(string(key), string(value))
+		if err != nil && filterErr == nil {
+			filterErr = err
+		}
+		if ok && err == nil {
+			filter.Attributes = append(filter.Attributes, f)
+		}
+	})
+	if filterErr == nil {
+		filter.Sort, filterErr = services.ParseSort(c.Query("sort"))
+	}
+	var facets []string
+	if filterErr == nil {
+		facets, filterErr = services.ParseFacets(c.Query("facets"))
+	}
+	if filterErr != nil {
+		_, resp := validationFailed(c, filterErr)
+		return resp
+	}
+
+	contents, page, err := services.GetAllContent(filter)
 	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
 		return apierrors.Internal("Failed to retrieve contents: " + err.Error())
 	}
 
-	return c.JSON(fiber.Map{
-		"data": contents,
-		"meta": fiber.Map{
-			"total": total,
-			"page":  filter.Page,
-			"limit": filter.Limit,
-		},
-	})
+	items := make([]*models.Content, len(contents))
+	for i := range contents {
+		items[i] = &contents[i]
+	}
+	if err := services.LoadReferences(items, c.Query("include"), viewer); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to load references: " + err.Error())
+	}
+
+	data, err := fields.ProjectAll(contents)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	response := fiber.Map{
+		"data": data,
+		"meta": pageMeta(params, page),
+	}
+	if len(facets) > 0 {
+		if response["facets"], err = services.GetContentFacets(filter, facets); err != nil {
+			return apierrors.Internal("Failed to compute facets: " + err.Error())
+		}
+	}
+	return c.JSON(response)
 }
 
 // GetContent godoc
@@ -128,16 +213,248 @@ func GetAllContent(c *fiber.Ctx) error {
 // @Tags Content
 // @Produce json
 // @Param id path int true "Content ID"
+// @Param render query string false "Also render blocks into the rendered field (html, markdown, text)"
+// @Param include query string false "Comma separated reference fields to expand, dotted for nesting (e.g. products.manufacturer)"
+// @Param fields query string false "Comma separated fields to return (e.g. id,title,blocks)"
+// @Param preview_token query string false "Preview token from POST /api/content/{id}/preview-token; returns the item even if unpublished"
+// @Param revision query string false "live (default) or draft; the draft revision of published content requires content.update"
 // @Success 200 {object} models.Content
+// @Header 200 {string} ETag "Version tag to send as If-Match when saving"
+// @Failure 400 {object} apierrors.AppError
+// @Failure 401 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
 // @Failure 404 {object} apierrors.AppError
 // @Router /api/content/{id} [get]
 func GetContent(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
-	content, err := services.GetContentByID(uint(id))
+	fields, err := detailFields(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+
+	viewer := viewerFrom(c)
+	if token := c.Query("preview_token"); token != "" {
+		// The token unlocks this item only; references are still expanded for the viewer
+		preview, err := auth.ParsePreviewToken(token)
+		if err != nil || preview.ContentID != uint(id) {
+			return apierrors.New(fiber.StatusUnauthorized, "Invalid or expired preview token")
+		}
+		content, err := services.GetContentPreview(uint(id), preview.Version, fields)
+		if err != nil {
+			return apierrors.NotFound("Content not found")
+		}
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		return sendContent(c, content, viewer, fields)
+	}
+
+	revision := c.Query("revision", models.RevisionLive)
+	if revision != models.RevisionLive && revision != models.RevisionDraft {
+		return apierrors.BadRequest("revision must be live or draft")
+	}
+
+	content, err := services.GetVisibleContent(uint(id), viewer, fields)
 	if err != nil {
 		return apierrors.NotFound("Content not found")
 	}
-	return c.JSON(content)
+
+	// Editors see whether unpublished edits exist and may read them. Their ETag covers
+	// the draft too, since that is what their writes change.
+	canEdit := false
+	if viewer.UserID != 0 {
+		if canEdit, err = auth.HasPermission(viewer.UserID, "content.update"); err != nil {
+			return apierrors.Internal(err.Error())
+		}
+	}
+	if revision == models.RevisionDraft && !canEdit {
+		return apierrors.New(fiber.StatusForbidden, "Forbidden: reading drafts requires content.update")
+	}
+	tag := services.VersionTag(content.Version, 0)
+	if canEdit {
+		if tag, err = services.ContentVersionTag(content); err != nil {
+			return apierrors.Internal(err.Error())
+		}
+		content.HasDraft = tag != services.VersionTag(content.Version, 0)
+	}
+	if revision == models.RevisionDraft {
+		if err := services.ApplyDraft(content); err != nil {
+			return apierrors.Internal("Failed to load draft: " + err.Error())
+		}
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+	}
+	setVersionTag(c, tag)
+	return sendContent(c, content, viewer, fields)
+}
+
+// CreatePreviewToken godoc
+// @Summary Create a preview link
+// @Description Mints an expiring token that lets anyone read this content item, including drafts, via GET /api/content/{id}?preview_token=
+// @Tags Content
+// @Accept json
+// @Produce json
+// @Param id path int true "Content ID"
+// @Param request body models.PreviewTokenRequest false "Version to pin and expiry"
+// @Success 200 {object} models.PreviewTokenResponse
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/preview-token [post]
+func CreatePreviewToken(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.PreviewTokenRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+		}
+	}
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	token, expiresAt, err := services.CreatePreviewToken(uint(id), req.Version, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.NotFound("Content not found")
+	}
+
+	return c.JSON(models.PreviewTokenResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		URL:       "/api/content/" + strconv.Itoa(id) + "?preview_token=" + url.QueryEscape(token),
+	})
+}
+
+// GetContentBySlug godoc
+// @Summary Get content by slug
+// @Description Retrieves content by slug and language. Slugs that were renamed answer with a 301 pointing at the current slug, or a 200 redirect payload with no_redirect=true.
+// @Tags Content
+// @Produce json
+// @Param slug path string true "Content slug"
+// @Param lang query string false "Language code; any language when omitted"
+// @Param no_redirect query bool false "Return the redirect payload with 200 instead of 301"
+// @Param render query string false "Also render blocks into the rendered field (html, markdown, text)"
+// @Param include query string false "Comma separated reference fields to expand, dotted for nesting (e.g. products.manufacturer)"
+// @Param fields query string false "Comma separated fields to return (e.g. id,title,blocks)"
+// @Success 200 {object} models.Content
+// @Success 301 {object} models.SlugRedirectResponse
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Router /api/content/slug/{slug} [get]
+func GetContentBySlug(c *fiber.Ctx) error {
+	fields, err := detailFields(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+
+	viewer := viewerFrom(c)
+	content, moved, err := services.GetContentBySlug(c.Params("slug"), c.Query("lang"), viewer, fields)
+	if err != nil {
+		return apierrors.NotFound("Content not found")
+	}
+	if !moved {
+		return sendContent(c, content, viewer, fields)
+	}
+
+	location := "/api/content/slug/" + url.PathEscape(content.Slug) + "?lang=" + url.QueryEscape(content.Language)
+	status := fiber.StatusMovedPermanently
+	if c.QueryBool("no_redirect") {
+		status = fiber.StatusOK
+	} else {
+		c.Set(fiber.HeaderLocation, location)
+	}
+	return c.Status(status).JSON(models.SlugRedirectResponse{
+		Redirect: true,
+		ID:       content.ID,
+		Slug:     content.Slug,
+		Language: content.Language,
+		Location: location,
+	})
+}
+
+// detailFields parses ?fields= for single content responses; ?include= and ?render= add
+// the fields they fill
+func detailFields(c *fiber.Ctx) (*services.ContentFields, error) {
+	fields, err := services.ParseContentFields(c.Query("fields"))
+	if err != nil {
+		return nil, err
+	}
+	if c.Query("include") != "" {
+		fields.Add("references")
+	}
+	if c.Query("render") != "" {
+		fields.Add("rendered")
+	}
+	return fields, nil
+}
+
+// sendContent expands references, renders and projects a single content item
+func sendContent(c *fiber.Ctx, content *models.Content, viewer services.Viewer, fields *services.ContentFields) error {
+	if err := services.LoadReferences([]*models.Content{content}, c.Query("include"), viewer); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to load references: " + err.Error())
+	}
+
+	if render := c.Query("render"); render != "" {
+		format, err := renderer.ParseFormat(render)
+		if err != nil {
+			return apierrors.BadRequest(err.Error())
+		}
+		if content.Rendered, err = services.RenderContent(content, format); err != nil {
+			return apierrors.Internal("Failed to render content: " + err.Error())
+		}
+	}
+
+	data, err := fields.Project(content)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	return c.JSON(data)
+}
+
+// RenderContent godoc
+// @Summary Render content
+// @Description Renders the content blocks server-side as HTML, Markdown or plain text
+// @Tags Content
+// @Produce json
+// @Param id path int true "Content ID"
+// @Param format query string false "Output format: html (default), markdown or text"
+// @Success 200 {object} models.RenderedContentResponse
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Router /api/content/{id}/render [get]
+func RenderContent(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	format, err := renderer.ParseFormat(c.Query("format"))
+	if err != nil {
+		return apierrors.BadRequest(err.Error())
+	}
+
+	content, err := services.GetVisibleContent(uint(id), viewerFrom(c), nil)
+	if err != nil {
+		return apierrors.NotFound("Content not found")
+	}
+
+	rendered, err := services.RenderContent(content, format)
+	if err != nil {
+		return apierrors.Internal("Failed to render content: " + err.Error())
+	}
+
+	return c.JSON(models.RenderedContentResponse{
+		ID:       content.ID,
+		Format:   string(format),
+		Rendered: rendered,
+	})
 }
 
 // UpdateContent godoc
@@ -148,8 +465,12 @@ func GetContent(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path int true "Content ID"
 // @Param content body models.ContentUpdateRequest true "Update Request"
+// @Param If-Match header string false "ETag the edit is based on; takes precedence over expected_version"
 // @Success 200 {object} models.Content
+// @Header 200 {string} ETag "Version tag after the update"
 // @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
 // @Failure 500 {object} apierrors.AppError
 // @Security Bearer
 // @Router /api/content/{id} [put]
@@ -169,11 +490,25 @@ func UpdateContent(c *fiber.Ctx) error {
 		})
 	}
 
-	updatedContent, err := services.UpdateContent(uint(id), req.Title, req.Body, req.Type, req.Attributes, req.Status, req.Language, req.CategoryIDs, req.Tags, req.PublishedAt, req.Blocks)
+	updatedContent, err := services.UpdateContent(uint(id), req.Title, req.Slug, req.Body, req.Type, req.Attributes, req.Status, req.Language, req.CategoryIDs, req.Tags, req.PublishedAt, req.UnpublishAt, req.Blocks, req.References, req.ChangeMessage, expectedVersion(c, req.ExpectedVersion), auth.UserID(c))
 	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if ok, resp := versionConflict(c, err); ok {
+			return resp
+		}
+		if failed := transitionFailed(err); failed != nil {
+			return failed
+		}
 		return apierrors.Internal("Failed to update content: " + err.Error())
 	}
 
+	tag, err := services.ContentVersionTag(updatedContent)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	setVersionTag(c, tag)
 	return c.JSON(updatedContent)
 }
 
@@ -187,6 +522,7 @@ func UpdateContent(c *fiber.Ctx) error {
 // @Param content body models.ContentCreateRequest true "Translated Content"
 // @Success 200 {object} models.Content
 // @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
 // @Failure 500 {object} apierrors.AppError
 // @Security Bearer
 // @Router /api/content/{id}/localize [post]
@@ -198,19 +534,27 @@ func AddTranslation(c *fiber.Ctx) error {
 	}
 
 	translation := &models.Content{
-		Title:      req.Title,
-		Slug:       req.Slug,
-		Body:       req.Body,
-		Type:       req.Type,
-		Attributes: req.Attributes,
-		Status:     req.Status,
-		Language:   req.Language,
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Body:          req.Body,
+		Type:          req.Type,
+		Attributes:    req.Attributes,
+		Status:        req.Status,
+		Language:      req.Language,
+		UnpublishAt:   req.UnpublishAt,
+		ChangeMessage: req.ChangeMessage,
 	}
 
 	// Note: Taxonomies for translations should theoretically be same as original or localized?
 	// For now, let's keep it simple and not carry over taxonomies automatically, or allow setting them.
 	// Users can update them later.
-	if err := services.AddTranslation(uint(id), translation); err != nil {
+	if err := services.AddTranslation(uint(id), translation, req.Blocks, req.References, auth.UserID(c)); err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if failed := transitionFailed(err); failed != nil {
+			return failed
+		}
 		return apierrors.BadRequest("Failed to add translation: " + err.Error())
 	}
 
@@ -236,14 +580,48 @@ func GetHistory(c *fiber.Ctx) error {
 	return c.JSON(history)
 }
 
+// GetContentDiff godoc
+// @Summary Compare content versions
+// @Description Lists the changes between two versions: scalar fields, attributes (structurally), blocks (added, removed or changed) and a line and word diff of the body
+// @Tags Content
+// @Produce json
+// @Param id path int true "Content ID"
+// @Param from query int true "Old version"
+// @Param to query int false "New version (defaults to the current version)"
+// @Success 200 {object} services.ContentDiff
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/diff [get]
+func GetContentDiff(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	result, err := services.DiffContent(uint(id), c.QueryInt("from"), c.QueryInt("to"))
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Content not found")
+		}
+		return apierrors.Internal("Failed to compare versions: " + err.Error())
+	}
+	return c.JSON(result)
+}
+
 // RevertContent godoc
 // @Summary Revert content version
-// @Description Reverts content to a specific version
+// @Description Restores the title, slug, body, blocks, attributes, publish dates, categories, tags and references of a version as a new version. The workflow status is kept. Published content and content with a draft get the version in their draft, and the draft revision is returned.
 // @Tags Content
+// @Accept json
 // @Produce json
 // @Param id path int true "Content ID"
 // @Param version path int true "Version number"
+// @Param revert body models.ContentRevertRequest false "Change message"
+// @Param If-Match header string false "ETag the revert is based on; takes precedence over expected_version"
 // @Success 200 {object} models.Content
+// @Failure 400 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
 // @Failure 500 {object} apierrors.AppError
 // @Security Bearer
 // @Router /api/content/{id}/revert/{version} [post]
@@ -251,10 +629,35 @@ func RevertContent(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
 	version, _ := strconv.Atoi(c.Params("version"))
 
-	revertedContent, err := services.RevertContent(uint(id), version)
+	req := new(models.ContentRevertRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+		}
+		if errors := validator.ValidateStruct(req); len(errors) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"errors":  errors,
+				"message": "Validation failed",
+			})
+		}
+	}
+
+	revertedContent, err := services.RevertContent(uint(id), version, req.ChangeMessage, expectedVersion(c, req.ExpectedVersion), auth.UserID(c))
 	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if ok, resp := versionConflict(c, err); ok {
+			return resp
+		}
 		return apierrors.Internal("Failed to revert content: " + err.Error())
 	}
+	tag, err := services.ContentVersionTag(revertedContent)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	setVersionTag(c, tag)
 	return c.JSON(revertedContent)
 }
 
@@ -263,14 +666,33 @@ func RevertContent(c *fiber.Ctx) error {
 // @Description Soft deletes a content item
 // @Tags Content
 // @Produce json
+// @Accept json
 // @Param id path int true "Content ID"
+// @Param delete body models.ContentDeleteRequest false "Expected version"
+// @Param If-Match header string false "ETag the deletion is based on; takes precedence over expected_version"
 // @Success 200 {object} map[string]bool
+// @Failure 404 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
 // @Failure 500 {object} apierrors.AppError
 // @Security Bearer
 // @Router /api/content/{id} [delete]
 func DeleteContent(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
-	if err := services.DeleteContent(uint(id)); err != nil {
+
+	req := new(models.ContentDeleteRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+		}
+	}
+
+	if err := services.DeleteContent(uint(id), expectedVersion(c, req.ExpectedVersion)); err != nil {
+		if ok, resp := versionConflict(c, err); ok {
+			return resp
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Content not found")
+		}
 		return apierrors.Internal("Failed to delete content: " + err.Error())
 	}
 	return c.JSON(fiber.Map{"success": true})
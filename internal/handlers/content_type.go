@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/validator"
+	"content-flow/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateContentType godoc
+// @Summary Register a content type
+// @Description Registers a content type and the JSON Schema its attributes must follow
+// @Tags Content Types
+// @Accept json
+// @Produce json
+// @Param contentType body models.ContentTypeRequest true "Content type"
+// @Success 200 {object} models.ContentType
+// @Failure 400 {object} apierrors.AppError
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content-types [post]
+func CreateContentType(c *fiber.Ctx) error {
+	req := new(models.ContentTypeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	contentType, err := services.CreateContentType(req.Name, req.Description, req.Schema, req.References)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to create content type: " + err.Error())
+	}
+
+	return c.JSON(contentType)
+}
+
+// GetAllContentTypes godoc
+// @Summary List content types
+// @Tags Content Types
+// @Produce json
+// @Success 200 {array} models.ContentType
+// @Router /api/content-types [get]
+func GetAllContentTypes(c *fiber.Ctx) error {
+	contentTypes, err := services.GetAllContentTypes()
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	return c.JSON(contentTypes)
+}
+
+// GetContentType godoc
+// @Summary Get content type by ID
+// @Tags Content Types
+// @Produce json
+// @Param id path int true "Content Type ID"
+// @Success 200 {object} models.ContentType
+// @Failure 404 {object} apierrors.AppError
+// @Router /api/content-types/{id} [get]
+func GetContentType(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	contentType, err := services.GetContentTypeByID(uint(id))
+	if err != nil {
+		return apierrors.NotFound("Content type not found")
+	}
+	return c.JSON(contentType)
+}
+
+// UpdateContentType godoc
+// @Summary Update content type
+// @Description Replaces the name, description and schema of a content type
+// @Tags Content Types
+// @Accept json
+// @Produce json
+// @Param id path int true "Content Type ID"
+// @Param contentType body models.ContentTypeRequest true "Content type"
+// @Success 200 {object} models.ContentType
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content-types/{id} [put]
+func UpdateContentType(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.ContentTypeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	if _, err := services.GetContentTypeByID(uint(id)); err != nil {
+		return apierrors.NotFound("Content type not found")
+	}
+
+	contentType, err := services.UpdateContentType(uint(id), req.Name, req.Description, req.Schema, req.References)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to update content type: " + err.Error())
+	}
+
+	return c.JSON(contentType)
+}
+
+// DeleteContentType godoc
+// @Summary Delete content type
+// @Description Removes a content type registration; existing content is left untouched
+// @Tags Content Types
+// @Produce json
+// @Param id path int true "Content Type ID"
+// @Success 200 {object} map[string]bool
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content-types/{id} [delete]
+func DeleteContentType(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := services.DeleteContentType(uint(id)); err != nil {
+		return apierrors.Internal("Failed to delete content type: " + err.Error())
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/services"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// PublishDraft godoc
+// @Summary Publish the draft
+// @Description Makes the working draft of published content its live revision, saved as a new version with the draft's change message. The workflow status is unchanged. The draft needs as many approvals as the workflow transitions into the content's status, from reviews requested on its current version tag.
+// @Tags Content
+// @Produce json
+// @Param id path int true "Content ID"
+// @Param If-Match header string false "ETag of the reviewed draft, e.g. \"5-d2\""
+// @Success 200 {object} models.Content
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/draft/publish [post]
+func PublishDraft(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	content, err := services.PublishDraft(uint(id), expectedVersion(c, ""), auth.UserID(c))
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if ok, resp := versionConflict(c, err); ok {
+			return resp
+		}
+		if errors.Is(err, services.ErrNoDraft) {
+			return apierrors.NotFound("Content has no draft")
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Content not found")
+		}
+		return apierrors.Internal("Failed to publish draft: " + err.Error())
+	}
+
+	setVersionTag(c, services.VersionTag(content.Version, 0))
+	return c.JSON(content)
+}
+
+// DiscardDraft godoc
+// @Summary Discard the draft
+// @Description Drops the unpublished edits; the live revision is kept
+// @Tags Content
+// @Produce json
+// @Param id path int true "Content ID"
+// @Success 200 {object} map[string]bool
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/draft [delete]
+func DiscardDraft(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := services.DiscardDraft(uint(id), auth.UserID(c)); err != nil {
+		if errors.Is(err, services.ErrNoDraft) {
+			return apierrors.NotFound("Content has no draft")
+		}
+		return apierrors.Internal("Failed to discard draft: " + err.Error())
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
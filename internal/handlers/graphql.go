@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"content-flow/internal/gql"
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// GraphQL godoc
+// @Summary GraphQL endpoint
+// @Description Queries content, translations, taxonomies, authors, comments and likes in one round trip. The schema includes a typed query per registered content type. Mutations require the same permissions as the REST API.
+// @Description Operations may nest fields 10 levels deep and have a complexity of at most 5000: every field counts 1 plus its selection, which counts 10 times under a list.
+// @Tags GraphQL
+// @Accept json
+// @Produce json
+// @Param request body GraphQLRequest true "GraphQL request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apierrors.AppError
+// @Router /graphql [post]
+func GraphQL(c *fiber.Ctx) error {
+	req := new(GraphQLRequest)
+	if c.Method() == fiber.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				return apierrors.BadRequest("Cannot parse variables: " + err.Error())
+			}
+		}
+	} else if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if req.Query == "" {
+		return apierrors.BadRequest("Missing query")
+	}
+
+	result := gql.Execute(c.UserContext(), auth.UserID(c), req.Query, req.Variables, req.OperationName)
+	return c.JSON(result)
+}
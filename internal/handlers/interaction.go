@@ -23,6 +23,7 @@ type CreateCommentRequest struct {
 // @Success 200 {object} models.Comment
 // @Failure 400 {object} apierrors.AppError
 // @Security Bearer
+// @Failure 404 {object} apierrors.AppError
 // @Router /api/content/{id}/comments [post]
 func AddComment(c *fiber.Ctx) error {
 	contentID, _ := strconv.Atoi(c.Params("id"))
@@ -36,6 +37,11 @@ func AddComment(c *fiber.Ctx) error {
 	if req.Body == "" {
 		return apierrors.BadRequest("Comment body cannot be empty")
 	}
+	if visible, err := services.CanView(uint(contentID), viewerFrom(c)); err != nil {
+		return apierrors.Internal(err.Error())
+	} else if !visible {
+		return apierrors.NotFound("Content not found")
+	}
 
 	comment, err := services.AddComment(userID, uint(contentID), req.Body)
 	if err != nil {
@@ -51,10 +57,33 @@ func AddComment(c *fiber.Ctx) error {
 // @Tags Engagement
 // @Produce json
 // @Param id path int true "Content ID"
+// @Param cursor query string false "Keyset pagination: next_cursor of the previous page, empty for the first page. Switches the response to {data, meta}"
+// @Param pagination query string false "Set to 'cursor' to use keyset pagination"
+// @Param limit query int false "Items per page in cursor mode (default 10, max 100)"
+// @Param total query bool false "Include the total count in cursor mode"
 // @Success 200 {array} models.Comment
+// @Failure 404 {object} apierrors.AppError
 // @Router /api/content/{id}/comments [get]
 func GetComments(c *fiber.Ctx) error {
 	contentID, _ := strconv.Atoi(c.Params("id"))
+	if visible, err := services.CanView(uint(contentID), viewerFrom(c)); err != nil {
+		return apierrors.Internal(err.Error())
+	} else if !visible {
+		return apierrors.NotFound("Content not found")
+	}
+	params, err := pageParams(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+	if params.Keyset {
+		comments, page, err := services.GetCommentsPage(uint(contentID), params)
+		if err != nil {
+			return apierrors.Internal("Failed to fetch comments")
+		}
+		return c.JSON(fiber.Map{"data": comments, "meta": pageMeta(params, page)})
+	}
+
 	comments, err := services.GetComments(uint(contentID))
 	if err != nil {
 		return apierrors.Internal("Failed to fetch comments")
@@ -75,10 +104,16 @@ type LikeResponse struct {
 // @Param id path int true "Content ID"
 // @Success 200 {object} LikeResponse
 // @Security Bearer
+// @Failure 404 {object} apierrors.AppError
 // @Router /api/content/{id}/like [post]
 func ToggleLike(c *fiber.Ctx) error {
 	contentID, _ := strconv.Atoi(c.Params("id"))
 	userID := uint(c.Locals("user_id").(float64))
+	if visible, err := services.CanView(uint(contentID), viewerFrom(c)); err != nil {
+		return apierrors.Internal(err.Error())
+	} else if !visible {
+		return apierrors.NotFound("Content not found")
+	}
 
 	liked, err := services.ToggleLike(userID, uint(contentID))
 	if err != nil {
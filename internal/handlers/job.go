@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/queue"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetJobs godoc
+// @Summary List background jobs
+// @Description Lists queued, running, finished and dead-lettered jobs, newest first
+// @Tags Jobs
+// @Produce json
+// @Param type query string false "Job type, e.g. webhook.deliver"
+// @Param status query string false "PENDING, RUNNING, SUCCEEDED, DEAD or CANCELLED"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10, max 100)"
+// @Param cursor query string false "Keyset pagination: next_cursor of the previous page, empty for the first page"
+// @Success 200 {array} models.Job
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/jobs [get]
+func GetJobs(c *fiber.Ctx) error {
+	params, err := pageParams(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+	filter := queue.Filter{Type: c.Query("type"), Status: strings.ToUpper(c.Query("status"))}
+
+	jobs, page, err := queue.List(filter, params)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	return c.JSON(fiber.Map{"data": jobs, "meta": pageMeta(params, page)})
+}
+
+// GetJob godoc
+// @Summary Get a background job
+// @Tags Jobs
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/jobs/{id} [get]
+func GetJob(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	job, err := queue.Get(uint(id))
+	if err != nil {
+		return apierrors.NotFound("Job not found")
+	}
+	return c.JSON(job)
+}
+
+// RetryJob godoc
+// @Summary Retry a dead or cancelled job
+// @Description Queues the job again with its attempts reset. Jobs of recurring types (content.schedule) cannot be retried; their next run is always queued.
+// @Tags Jobs
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/jobs/{id}/retry [post]
+func RetryJob(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	job, err := queue.Retry(uint(id))
+	if err != nil {
+		return jobError(err)
+	}
+	return c.JSON(job)
+}
+
+// CancelJob godoc
+// @Summary Cancel a pending job
+// @Description Stops a pending job, including one waiting for a retry. Jobs of recurring types (content.schedule) cannot be cancelled.
+// @Tags Jobs
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/jobs/{id}/cancel [post]
+func CancelJob(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	job, err := queue.Cancel(uint(id))
+	if err != nil {
+		return jobError(err)
+	}
+	return c.JSON(job)
+}
+
+func jobError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apierrors.NotFound("Job not found")
+	case errors.Is(err, queue.ErrNotRetryable), errors.Is(err, queue.ErrNotCancellable), errors.Is(err, queue.ErrRecurring):
+		return apierrors.New(fiber.StatusConflict, err.Error())
+	}
+	return apierrors.Internal(err.Error())
+}
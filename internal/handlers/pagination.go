@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"content-flow/internal/pkgs/pagination"
+	"content-flow/internal/pkgs/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// pageParams reads page, limit, cursor, pagination and total from the query string.
+// Keyset mode is opted into with ?pagination=cursor or by sending ?cursor= (empty for
+// the first page). The total count is on by default for offset paging and off for
+// keyset paging; ?total=true|false overrides either.
+func pageParams(c *fiber.Ctx) (pagination.Params, error) {
+	p := pagination.Params{
+		Keyset: c.Query("pagination") == "cursor" || c.Context().QueryArgs().Has("cursor"),
+		Page:   c.QueryInt("page", 1),
+		Limit:  c.QueryInt("limit", pagination.DefaultLimit),
+	}
+	p.SkipTotal = !c.QueryBool("total", !p.Keyset)
+
+	if p.Keyset {
+		cursor, err := pagination.Decode(c.Query("cursor"))
+		if err != nil {
+			return p, validator.NewValidationError([]*validator.ErrorResponse{{Field: "cursor", Message: err.Error()}})
+		}
+		p.Cursor = cursor
+	}
+	p.Normalize()
+	return p, nil
+}
+
+// pageMeta builds the "meta" object of a paginated response
+func pageMeta(p pagination.Params, page pagination.Page) fiber.Map {
+	meta := fiber.Map{"limit": p.Limit}
+	if page.HasTotal {
+		meta["total"] = page.Total
+	}
+	if p.Keyset {
+		meta["next_cursor"] = nil
+		if page.NextCursor != "" {
+			meta["next_cursor"] = page.NextCursor
+		}
+	} else {
+		meta["page"] = p.Page
+	}
+	return meta
+}
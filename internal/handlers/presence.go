@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/presence"
+	"content-flow/internal/services"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	presencePingInterval = 25 * time.Second
+	presenceReadTimeout  = 60 * time.Second // Without a message or pong, the client is gone
+	presenceWriteTimeout = 10 * time.Second
+)
+
+// presenceMessage is what clients send on the presence socket
+type presenceMessage struct {
+	Type  string `json:"type"`  // "state", "lock" (take or renew) or "unlock"
+	State string `json:"state"` // "viewing" or "editing", for "state"
+}
+
+// presenceError answers a message the client may not send
+type presenceError struct {
+	Type    string         `json:"type"` // "error"
+	Message string         `json:"message"`
+	Lock    *presence.Lock `json:"lock,omitempty"` // Who holds the lock, when taking it failed
+}
+
+// ContentPresenceHandshake godoc
+// @Summary Join the presence room of content (WebSocket)
+// @Description Upgrades to a WebSocket that shares who has the item open, its edit lock and saves. Browsers pass the JWT as the token query parameter.
+// @Description Server events: {"type":"state","members":[...],"lock":{...}} on joining, then "presence" (members), "lock" (lock, null when free), "version" (version, version_tag and the user_id who saved) and "deleted".
+// @Description Client messages: {"type":"state","state":"viewing|editing"}, {"type":"lock"} to take or renew the soft edit lock (expires after 30s) and {"type":"unlock"}. Editing and locking require content.update.
+// @Tags Content
+// @Param id path int true "Content ID"
+// @Param token query string false "JWT, when the Authorization header cannot be set"
+// @Success 101
+// @Failure 401 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Failure 426 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/presence [get]
+func ContentPresenceHandshake(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return apierrors.New(fiber.StatusUpgradeRequired, "Expected a WebSocket upgrade")
+	}
+	id, _ := strconv.Atoi(c.Params("id"))
+	userID := auth.UserID(c)
+
+	user, err := services.GetUserByID(userID)
+	if err != nil {
+		return apierrors.New(fiber.StatusUnauthorized, "User not found")
+	}
+	if _, err := services.CurrentVersion(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Content not found")
+		}
+		return apierrors.Internal(err.Error())
+	}
+	canEdit, err := auth.HasPermission(userID, "content.update")
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+
+	c.Locals("presence_user", presence.User{ID: user.ID, Username: user.Username})
+	c.Locals("can_edit", canEdit)
+	return c.Next()
+}
+
+// ContentPresence serves the presence socket of a content item (see ContentPresenceHandshake)
+func ContentPresence(conn *websocket.Conn) {
+	id, _ := strconv.Atoi(conn.Params("id"))
+	user, _ := conn.Locals("presence_user").(presence.User)
+	canEdit, _ := conn.Locals("can_edit").(bool)
+
+	client := presence.Join(uint(id), user)
+	done := make(chan struct{})
+	go writePresence(conn, client, done)
+	defer func() {
+		client.Leave()
+		<-done
+	}()
+
+	// Read after joining, so a save in between is not missed
+	version, err := services.CurrentVersion(uint(id))
+	if err != nil {
+		client.Send(presenceError{Type: "error", Message: "Failed to load content: " + err.Error()})
+		return
+	}
+	client.Send(version)
+
+	conn.SetReadDeadline(time.Now().Add(presenceReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(presenceReadTimeout))
+	})
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(presenceReadTimeout))
+
+		var msg presenceMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			client.Send(presenceError{Type: "error", Message: "Cannot parse JSON: " + err.Error()})
+			continue
+		}
+		switch msg.Type {
+		case "state":
+			switch {
+			case msg.State != presence.Viewing && msg.State != presence.Editing:
+				client.Send(presenceError{Type: "error", Message: "state must be viewing or editing"})
+			case msg.State == presence.Editing && !canEdit:
+				client.Send(presenceError{Type: "error", Message: "Forbidden: Missing permission content.update"})
+			default:
+				client.SetState(msg.State)
+			}
+		case "lock":
+			if !canEdit {
+				client.Send(presenceError{Type: "error", Message: "Forbidden: Missing permission content.update"})
+			} else if lock, ok := client.Lock(); !ok && lock != nil {
+				client.Send(presenceError{Type: "error", Message: "Locked by " + lock.Username, Lock: lock})
+			}
+		case "unlock":
+			client.Unlock()
+		default:
+			client.Send(presenceError{Type: "error", Message: "Unknown message type " + strconv.Quote(msg.Type)})
+		}
+	}
+}
+
+// writePresence sends the events of client and keeps the connection alive with pings.
+// It closes the connection when the client is dropped or a write fails, which ends the
+// read loop too.
+func writePresence(conn *websocket.Conn, client *presence.Client, done chan<- struct{}) {
+	defer close(done)
+	defer conn.Close()
+
+	ping := time.NewTicker(presencePingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case msg, ok := <-client.Messages():
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(presenceWriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(presenceWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/validator"
+	"content-flow/internal/services"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RequestReview godoc
+// @Summary Request a review
+// @Description Assigns reviewers to the current version of a content item. Reviewers need content.approve. Drafts move to IN_REVIEW.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Content ID"
+// @Param review body models.ReviewRequestCreateRequest true "Reviewers"
+// @Success 200 {object} models.ReviewRequest
+// @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/reviews [post]
+func RequestReview(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.ReviewRequestCreateRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	request, err := services.RequestReview(uint(id), auth.UserID(c), req.ReviewerIDs, req.Note)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if failed := transitionFailed(err); failed != nil {
+			return failed
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Content not found")
+		}
+		return apierrors.Internal("Failed to request review: " + err.Error())
+	}
+
+	return c.JSON(request)
+}
+
+// GetContentReviews godoc
+// @Summary List review requests of content
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "Content ID"
+// @Success 200 {array} models.ReviewRequest
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/reviews [get]
+func GetContentReviews(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	requests, err := services.GetContentReviews(uint(id))
+	if err != nil {
+		return apierrors.Internal("Failed to retrieve reviews: " + err.Error())
+	}
+	return c.JSON(requests)
+}
+
+// GetAssignedReviews godoc
+// @Summary List my pending reviews
+// @Description Open review requests assigned to the current user that still need a decision
+// @Tags Reviews
+// @Produce json
+// @Success 200 {array} models.ReviewRequest
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/reviews/assigned [get]
+func GetAssignedReviews(c *fiber.Ctx) error {
+	requests, err := services.GetAssignedReviews(auth.UserID(c))
+	if err != nil {
+		return apierrors.Internal("Failed to retrieve reviews: " + err.Error())
+	}
+	return c.JSON(requests)
+}
+
+// DecideReview godoc
+// @Summary Approve or request changes
+// @Description Records the current user's decision on a review request they are assigned to. Approving requires content.approve, requesting changes content.review. Approvals count towards transitions with required_approvals while the content stays at the reviewed version.
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review Request ID"
+// @Param decision body models.ReviewDecisionRequest true "Decision"
+// @Success 200 {object} models.ReviewRequest
+// @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/reviews/{id}/decision [post]
+func DecideReview(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.ReviewDecisionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	request, err := services.DecideReview(uint(id), auth.UserID(c), req.Decision, req.Note)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if errors.Is(err, services.ErrNotReviewer) || errors.Is(err, services.ErrReviewForbidden) {
+			return apierrors.New(fiber.StatusForbidden, "Forbidden: "+err.Error())
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Review request not found")
+		}
+		return apierrors.Internal("Failed to record decision: " + err.Error())
+	}
+
+	return c.JSON(request)
+}
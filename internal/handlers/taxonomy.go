@@ -49,9 +49,26 @@ func GetAllCategories(c *fiber.Ctx) error {
 // @Summary Get all tags
 // @Tags Taxonomies
 // @Produce json
+// @Param cursor query string false "Keyset pagination: next_cursor of the previous page, empty for the first page. Switches the response to {data, meta}"
+// @Param pagination query string false "Set to 'cursor' to use keyset pagination"
+// @Param limit query int false "Items per page in cursor mode (default 10, max 100)"
+// @Param total query bool false "Include the total count in cursor mode"
 // @Success 200 {array} models.Tag
 // @Router /api/tags [get]
 func GetAllTags(c *fiber.Ctx) error {
+	params, err := pageParams(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+	if params.Keyset {
+		tags, page, err := services.GetTagsPage(params)
+		if err != nil {
+			return apierrors.Internal(err.Error())
+		}
+		return c.JSON(fiber.Map{"data": tags, "meta": pageMeta(params, page)})
+	}
+
 	tags, err := services.GetAllTags()
 	if err != nil {
 		return apierrors.Internal(err.Error())
@@ -33,11 +33,17 @@ func GetProfile(c *fiber.Ctx) error {
 
 // GetUserStories godoc
 // @Summary Get user stories
-// @Description Get published stories by username
+// @Description Get stories by username: published ones, plus drafts for the author and users with content.read
 // @Tags Users
 // @Produce json
 // @Param username path string true "Username"
+// @Param cursor query string false "Keyset pagination: next_cursor of the previous page, empty for the first page. Switches the response to {data, meta}"
+// @Param pagination query string false "Set to 'cursor' to use keyset pagination"
+// @Param limit query int false "Items per page in cursor mode (default 10, max 100)"
+// @Param total query bool false "Include the total count in cursor mode"
+// @Param fields query string false "Comma separated fields to return (e.g. id,title,slug,tags)"
 // @Success 200 {array} models.Content
+// @Failure 400 {object} apierrors.AppError
 // @Router /api/users/{username}/stories [get]
 func GetUserStories(c *fiber.Ctx) error {
 	username := c.Params("username")
@@ -46,12 +52,39 @@ func GetUserStories(c *fiber.Ctx) error {
 		return apierrors.NotFound("User not found")
 	}
 
-	stories, err := services.GetUserStories(user.ID)
+	params, err := pageParams(c)
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+	fields, err := services.ParseContentFields(c.Query("fields"))
+	if err != nil {
+		_, resp := validationFailed(c, err)
+		return resp
+	}
+
+	if params.Keyset {
+		stories, page, err := services.GetUserStoriesPage(user.ID, viewerFrom(c), params, fields)
+		if err != nil {
+			return apierrors.Internal(err.Error())
+		}
+		data, err := fields.ProjectAll(stories)
+		if err != nil {
+			return apierrors.Internal(err.Error())
+		}
+		return c.JSON(fiber.Map{"data": data, "meta": pageMeta(params, page)})
+	}
+
+	stories, err := services.GetUserStories(user.ID, viewerFrom(c), fields)
 	if err != nil {
 		return apierrors.Internal(err.Error())
 	}
 
-	return c.JSON(stories)
+	data, err := fields.ProjectAll(stories)
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	return c.JSON(data)
 }
 
 // UpdateProfile godoc
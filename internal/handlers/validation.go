@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"content-flow/internal/pkgs/validator"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validationFailed renders validation errors raised by the service layer in the same
+// shape as the handler-level validator.ValidateStruct responses. It returns false when
+// err is not a validation error so the caller can fall back to its own error mapping.
+func validationFailed(c *fiber.Ctx, err error) (bool, error) {
+	var vErr *validator.ValidationError
+	if !errors.As(err, &vErr) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"success": false,
+		"errors":  vErr.Errors,
+		"message": "Validation failed",
+	})
+}
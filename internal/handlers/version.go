@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/services"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// expectedVersion returns the version tag a write is based on: the If-Match header, or
+// the expected_version of the body. "*" and a missing tag skip the check.
+func expectedVersion(c *fiber.Ctx, body models.ExpectedVersion) string {
+	match := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if match == "" {
+		return string(body)
+	}
+	if match == "*" {
+		return ""
+	}
+	return strings.Trim(strings.TrimPrefix(match, "W/"), `"`)
+}
+
+// setVersionTag serves a version tag as the ETag
+func setVersionTag(c *fiber.Ctx, tag string) {
+	c.Set(fiber.HeaderETag, `"`+tag+`"`)
+}
+
+// versionConflict responds 409 with the current version when err is a version conflict
+func versionConflict(c *fiber.Ctx, err error) (bool, error) {
+	var conflict *services.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return false, nil
+	}
+	setVersionTag(c, conflict.Current)
+	return true, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"code":            fiber.StatusConflict,
+		"success":         false,
+		"message":         err.Error(),
+		"current_version": conflict.Current,
+	})
+}
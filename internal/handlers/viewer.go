@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// viewerFrom resolves who is reading on routes behind auth.Optional(). A token for a
+// user that no longer exists reads like an anonymous visitor.
+func viewerFrom(c *fiber.Ctx) services.Viewer {
+	viewer, err := services.ViewerFor(auth.UserID(c))
+	if err != nil {
+		return services.Viewer{}
+	}
+	return viewer
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/apierrors"
+	"content-flow/internal/pkgs/auth"
+	"content-flow/internal/pkgs/validator"
+	"content-flow/internal/services"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// transitionFailed maps a missing transition permission to 403 and a status changed by
+// someone else to 409, or returns nil for other errors
+func transitionFailed(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTransitionForbidden):
+		return apierrors.New(fiber.StatusForbidden, "Forbidden: "+err.Error())
+	case errors.Is(err, services.ErrStatusChanged):
+		return apierrors.New(fiber.StatusConflict, err.Error())
+	}
+	return nil
+}
+
+// TransitionContent godoc
+// @Summary Change workflow status
+// @Description Moves content to another status along a configured workflow transition. Requires the transition's permission.
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param id path int true "Content ID"
+// @Param transition body models.ContentTransitionRequest true "Target status"
+// @Success 200 {object} models.Content
+// @Failure 400 {object} apierrors.AppError
+// @Failure 403 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Failure 409 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/transition [post]
+func TransitionContent(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.ContentTransitionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	content, err := services.TransitionContent(uint(id), req.Status, req.Comment, req.PublishedAt, auth.UserID(c))
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if failed := transitionFailed(err); failed != nil {
+			return failed
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Content not found")
+		}
+		return apierrors.Internal("Failed to change status: " + err.Error())
+	}
+
+	return c.JSON(content)
+}
+
+// GetContentTransitions godoc
+// @Summary Get workflow history
+// @Description Lists the workflow transitions taken by a content item, newest first
+// @Tags Workflow
+// @Produce json
+// @Param id path int true "Content ID"
+// @Success 200 {array} models.ContentTransition
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/content/{id}/transitions [get]
+func GetContentTransitions(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	history, err := services.GetContentTransitions(uint(id))
+	if err != nil {
+		return apierrors.Internal("Failed to retrieve workflow history: " + err.Error())
+	}
+	return c.JSON(history)
+}
+
+// GetWorkflowTransitions godoc
+// @Summary List workflow transitions
+// @Tags Workflow
+// @Produce json
+// @Success 200 {array} models.WorkflowTransition
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/workflow/transitions [get]
+func GetWorkflowTransitions(c *fiber.Ctx) error {
+	transitions, err := services.GetWorkflowTransitions()
+	if err != nil {
+		return apierrors.Internal(err.Error())
+	}
+	return c.JSON(transitions)
+}
+
+// CreateWorkflowTransition godoc
+// @Summary Add a workflow transition
+// @Description Allows moving content from from_status to to_status for holders of permission. event defaults to content.<to_status lowercased>.
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param transition body models.WorkflowTransitionRequest true "Transition"
+// @Success 200 {object} models.WorkflowTransition
+// @Failure 400 {object} apierrors.AppError
+// @Failure 500 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/workflow/transitions [post]
+func CreateWorkflowTransition(c *fiber.Ctx) error {
+	req := new(models.WorkflowTransitionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	transition, err := services.CreateWorkflowTransition(req)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		return apierrors.Internal("Failed to create workflow transition: " + err.Error())
+	}
+
+	return c.JSON(transition)
+}
+
+// UpdateWorkflowTransition godoc
+// @Summary Update a workflow transition
+// @Tags Workflow
+// @Accept json
+// @Produce json
+// @Param id path int true "Transition ID"
+// @Param transition body models.WorkflowTransitionRequest true "Transition"
+// @Success 200 {object} models.WorkflowTransition
+// @Failure 400 {object} apierrors.AppError
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/workflow/transitions/{id} [put]
+func UpdateWorkflowTransition(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+
+	req := new(models.WorkflowTransitionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return apierrors.BadRequest("Cannot parse JSON: " + err.Error())
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"errors":  errors,
+			"message": "Validation failed",
+		})
+	}
+
+	transition, err := services.UpdateWorkflowTransition(uint(id), req)
+	if err != nil {
+		if ok, resp := validationFailed(c, err); ok {
+			return resp
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Workflow transition not found")
+		}
+		return apierrors.Internal("Failed to update workflow transition: " + err.Error())
+	}
+
+	return c.JSON(transition)
+}
+
+// DeleteWorkflowTransition godoc
+// @Summary Delete a workflow transition
+// @Description Content already in a status keeps it, but can no longer take this transition
+// @Tags Workflow
+// @Produce json
+// @Param id path int true "Transition ID"
+// @Success 200 {object} map[string]bool
+// @Failure 404 {object} apierrors.AppError
+// @Security Bearer
+// @Router /api/workflow/transitions/{id} [delete]
+func DeleteWorkflowTransition(c *fiber.Ctx) error {
+	id, _ := strconv.Atoi(c.Params("id"))
+	if err := services.DeleteWorkflowTransition(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierrors.NotFound("Workflow transition not found")
+		}
+		return apierrors.Internal("Failed to delete workflow transition: " + err.Error())
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
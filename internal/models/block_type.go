@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// BlockType is a custom block registered on top of the built-in ones (paragraph, heading, ...)
+type BlockType struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Type        string         `gorm:"uniqueIndex" json:"type"` // Value of "type" in Content.Blocks, e.g. "callout"
+	Label       string         `json:"label"`
+	Description string         `json:"description"`
+	Schema      datatypes.JSON `json:"schema" swaggertype:"object"` // JSON Schema for the block's data
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type BlockTypeRequest struct {
+	Type        string          `json:"type" validate:"required,min=2,max=50"`
+	Label       string          `json:"label" validate:"required"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema" swaggertype:"object"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Bulk content actions
+const (
+	BulkPublish       = "publish"        // To PUBLISHED; published content with a draft publishes the draft
+	BulkUnpublish     = "unpublish"      // PUBLISHED to DRAFT
+	BulkSchedule      = "schedule"       // To SCHEDULED at published_at
+	BulkDelete        = "delete"         // Soft delete
+	BulkRestore       = "restore"        // Undo a soft delete
+	BulkAddTags       = "add_tags"       // Add tags, keeping the others
+	BulkRemoveTags    = "remove_tags"    // Remove tags, keeping the others
+	BulkSetCategories = "set_categories" // Replace the categories
+	BulkChangeAuthor  = "change_author"  // Hand the items to another user
+)
+
+// ContentBulkRequest applies one action to many content items, picked by IDs or by
+// filter (not both)
+type ContentBulkRequest struct {
+	Action string             `json:"action" validate:"required,oneof=publish unpublish schedule delete restore add_tags remove_tags set_categories change_author"`
+	IDs    []uint             `json:"ids" validate:"max=200"`
+	Filter *ContentBulkFilter `json:"filter"`
+	// All or nothing: the first failure rolls every item back. Otherwise each item is
+	// applied on its own and reported.
+	Atomic bool `json:"atomic"`
+
+	Tags        []string   `json:"tags"`         // add_tags, remove_tags
+	CategoryIDs []uint     `json:"category_ids"` // set_categories; empty clears them
+	AuthorID    uint       `json:"author_id"`    // change_author
+	PublishedAt *time.Time `json:"published_at"` // schedule (required), publish (defaults to now)
+	// Why the change was made, kept in the version history of every item
+	ChangeMessage string `json:"change_message" validate:"max=500"`
+	// Version tag per content ID that the change is based on; items changed since fail
+	ExpectedVersions map[uint]ExpectedVersion `json:"expected_versions" swaggertype:"object"`
+}
+
+// ContentBulkFilter selects content like the GET /api/content query parameters.
+// Restore matches deleted items only, every other action live ones.
+type ContentBulkFilter struct {
+	Search     string                   `json:"q"`
+	Type       string                   `json:"type"`
+	Status     string                   `json:"status"`
+	Language   string                   `json:"lang"`
+	Tags       []string                 `json:"tags"`
+	Attributes []ContentAttributeFilter `json:"attributes"`
+}
+
+// ContentAttributeFilter is filter[attributes.<path>][<op>]=<value> in JSON
+type ContentAttributeFilter struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"` // Defaults to eq
+	Value string `json:"value"`
+}
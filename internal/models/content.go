@@ -24,24 +24,47 @@ type Content struct {
 	AuthorID    uint           `gorm:"index" json:"author_id"`
 	Author      User           `json:"author,omitempty"`
 	PublishedAt *time.Time     `json:"published_at"`
+	UnpublishAt *time.Time     `json:"unpublish_at"` // Embargo end: hidden from the public and archived from then on
 	Blocks      datatypes.JSON `json:"blocks" swaggertype:"object"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	Rendered    string         `gorm:"-" json:"rendered,omitempty"`  // Blocks rendered on request (?render=html|markdown|text)
+	Snippet     string         `gorm:"-" json:"snippet,omitempty"`   // Highlighted search match as HTML (escaped text, <mark> around matches), set when listing with ?q=
+	HasDraft    bool           `gorm:"-" json:"has_draft,omitempty"` // Unpublished edits exist; set for users who may edit
+	Revision    string         `gorm:"-" json:"revision,omitempty"`  // "draft" when the draft revision was requested
+	// Reference field values: IDs, or the referenced Content/Media when requested via ?include=
+	References map[string]interface{} `gorm:"-" json:"references,omitempty" swaggertype:"object"`
+	// Who made the current version and why; kept in the history with the rest of the state
+	UpdatedByID   uint           `gorm:"index" json:"updated_by_id"`
+	ChangeMessage string         `json:"change_message"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	// Highest number of a discarded draft, so the tags of later drafts never repeat it
+	LastDraftVersion int `gorm:"not null;default:0" json:"-"`
 }
 
+// ContentVersion is the full state of a content item at one version. Snapshots taken
+// before slugs, dates, taxonomies and references were recorded have an empty Slug and
+// null CategoryIDs, Tags and References.
 type ContentVersion struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	ContentID  uint           `gorm:"index" json:"content_id"`
-	Title      string         `json:"title"`
-	Body       string         `json:"body"`
-	Blocks     datatypes.JSON `json:"blocks" swaggertype:"object"`
-	Type       string         `json:"type"`
-	Attributes string         `json:"attributes"`
-	Status     string         `json:"status"`
-	Language   string         `json:"language"`
-	Version    int            `json:"version"`
-	ChangedAt  time.Time      `json:"changed_at"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	ContentID     uint           `gorm:"index" json:"content_id"`
+	Title         string         `json:"title"`
+	Slug          string         `json:"slug"`
+	Body          string         `json:"body"`
+	Blocks        datatypes.JSON `json:"blocks" swaggertype:"object"`
+	Type          string         `json:"type"`
+	Attributes    string         `json:"attributes"`
+	Status        string         `json:"status"`
+	Language      string         `json:"language"`
+	PublishedAt   *time.Time     `json:"published_at"`
+	UnpublishAt   *time.Time     `json:"unpublish_at"`
+	CategoryIDs   datatypes.JSON `json:"category_ids" swaggertype:"array,integer"`
+	Tags          datatypes.JSON `json:"tags" swaggertype:"array,string"` // Tag names
+	References    datatypes.JSON `json:"references" swaggertype:"object"` // Field name -> target IDs
+	Version       int            `json:"version"`
+	UpdatedByID   uint           `json:"updated_by_id"` // User who made this version; 0 when unknown
+	ChangeMessage string         `json:"change_message"`
+	ChangedAt     time.Time      `json:"changed_at"`
 }
 
 type ContentUpdateRequest struct {
@@ -52,36 +75,91 @@ type ContentUpdateRequest struct {
 	// Since struct is used for generic update, let's just allow omitempty for flexibility or require if it's strictly PUT.
 	// Given previous update logic: services.UpdateContent takes all args.
 	// Let's add standard validation.
-	Body        string          `json:"body"`
-	Blocks      json.RawMessage `json:"blocks" swaggertype:"object"`
-	Type        string          `json:"type" validate:"omitempty"`
-	Attributes  string          `json:"attributes"`
-	Status      string          `json:"status" validate:"omitempty,oneof=DRAFT PUBLISHED SCHEDULED"`
-	Language    string          `json:"language" validate:"omitempty,len=2"`
-	CategoryIDs []uint          `json:"category_ids"`
-	Tags        []string        `json:"tags"` // Tag names
-	PublishedAt *time.Time      `json:"published_at"`
+	Slug        string            `json:"slug" validate:"omitempty,min=3"` // Old slugs keep resolving via /api/content/slug/:slug
+	Body        string            `json:"body"`
+	Blocks      json.RawMessage   `json:"blocks" swaggertype:"object"`
+	Type        string            `json:"type" validate:"omitempty"`
+	Attributes  string            `json:"attributes"`
+	Status      string            `json:"status"` // Workflow status; changing it takes a workflow transition, empty keeps the current one
+	Language    string            `json:"language" validate:"omitempty,len=2"`
+	CategoryIDs []uint            `json:"category_ids"`
+	Tags        []string          `json:"tags"` // Tag names
+	PublishedAt *time.Time        `json:"published_at"`
+	UnpublishAt *time.Time        `json:"unpublish_at"` // Expiry; must be after published_at
+	References  map[string][]uint `json:"references"`   // Field name -> target IDs; omitted fields are left unchanged
+	// Why the change was made, kept in the version history
+	ChangeMessage string `json:"change_message" validate:"max=500"`
+	// Version tag the edit is based on; the If-Match header takes precedence
+	ExpectedVersion ExpectedVersion `json:"expected_version" swaggertype:"string"`
 }
 
 type ContentCreateRequest struct {
-	Title       string          `json:"title" validate:"required,min=3"`
-	Slug        string          `json:"slug" validate:"required,min=3"`
-	Body        string          `json:"body"`
-	Blocks      json.RawMessage `json:"blocks" swaggertype:"object"`
-	Type        string          `json:"type" validate:"required"`
-	Attributes  string          `json:"attributes"`
-	Status      string          `json:"status" validate:"required,oneof=DRAFT PUBLISHED SCHEDULED"`
-	Language    string          `json:"language" validate:"required,len=2"`
-	CategoryIDs []uint          `json:"category_ids"`
-	Tags        []string        `json:"tags"` // Tag names
-	PublishedAt *time.Time      `json:"published_at"`
+	Title       string            `json:"title" validate:"required,min=3"`
+	Slug        string            `json:"slug" validate:"omitempty,min=3"` // Generated from the title when omitted
+	Body        string            `json:"body"`
+	Blocks      json.RawMessage   `json:"blocks" swaggertype:"object"`
+	Type        string            `json:"type" validate:"required"`
+	Attributes  string            `json:"attributes"`
+	Status      string            `json:"status"` // DRAFT when omitted; other statuses need a workflow transition from DRAFT
+	Language    string            `json:"language" validate:"required,len=2"`
+	CategoryIDs []uint            `json:"category_ids"`
+	Tags        []string          `json:"tags"` // Tag names
+	PublishedAt *time.Time        `json:"published_at"`
+	UnpublishAt *time.Time        `json:"unpublish_at"` // Expiry; must be after published_at
+	References  map[string][]uint `json:"references"`   // Field name -> target IDs
+	// Why the content was created, kept in the version history
+	ChangeMessage string `json:"change_message" validate:"max=500"`
+}
+
+// ContentRevertRequest is the optional body of a revert
+type ContentRevertRequest struct {
+	ChangeMessage   string          `json:"change_message" validate:"max=500"`     // Defaults to "Reverted to version N"
+	ExpectedVersion ExpectedVersion `json:"expected_version" swaggertype:"string"` // Or the If-Match header
+}
+
+// ContentDeleteRequest is the optional body of a delete
+type ContentDeleteRequest struct {
+	ExpectedVersion ExpectedVersion `json:"expected_version" swaggertype:"string"` // Or the If-Match header
+}
+
+// ExpectedVersion is the version tag a write is based on, as served in the ETag of
+// GET /api/content/:id. Both 5 and "5-d2" are accepted.
+type ExpectedVersion string
+
+func (v *ExpectedVersion) UnmarshalJSON(data []byte) error {
+	var tag string
+	if err := json.Unmarshal(data, &tag); err == nil {
+		*v = ExpectedVersion(tag)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*v = ExpectedVersion(number.String())
+	return nil
+}
+
+type RenderedContentResponse struct {
+	ID       uint   `json:"id"`
+	Format   string `json:"format"`
+	Rendered string `json:"rendered"`
 }
 
 type PaginatedContentResponse struct {
 	Data []Content `json:"data"`
 	Meta struct {
-		Total int64 `json:"total"`
-		Page  int   `json:"page"`
-		Limit int   `json:"limit"`
+		Total      int64   `json:"total,omitempty"`
+		Page       int     `json:"page,omitempty"`
+		Limit      int     `json:"limit"`
+		NextCursor *string `json:"next_cursor,omitempty"`
 	} `json:"meta"`
+	Facets map[string][]FacetBucket `json:"facets,omitempty"` // Only with ?facets=
+}
+
+// FacetBucket is one value of a facet with the number of matching content items
+type FacetBucket struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
 }
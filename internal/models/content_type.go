@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ContentType registers a Content.Type name together with the JSON Schema its Attributes must follow
+type ContentType struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"uniqueIndex" json:"name"` // Matches Content.Type, e.g. "Product"
+	Description string         `json:"description"`
+	Schema      datatypes.JSON `json:"schema" swaggertype:"object"`    // JSON Schema for Content.Attributes
+	References  datatypes.JSON `json:"references" swaggertype:"array"` // []ReferenceField
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ReferenceField declares a typed relation from content of this type to other content or media
+type ReferenceField struct {
+	Name         string   `json:"name"`                    // e.g. "products", "author_bio"
+	Target       string   `json:"target"`                  // "content" or "media"
+	Cardinality  string   `json:"cardinality"`             // "one" or "many"
+	ContentTypes []string `json:"content_types,omitempty"` // Optional whitelist of Content.Type for content targets
+}
+
+type ContentTypeRequest struct {
+	Name        string           `json:"name" validate:"required,min=2"`
+	Description string           `json:"description"`
+	Schema      json.RawMessage  `json:"schema" swaggertype:"object"`
+	References  []ReferenceField `json:"references"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Content revisions that can be requested with GET /api/content/:id?revision=
+const (
+	RevisionLive  = "live"
+	RevisionDraft = "draft"
+)
+
+// ContentDraft is the working draft of published content. Edits accumulate here while
+// readers keep getting the live revision, until the draft is published or discarded.
+type ContentDraft struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ContentID   uint           `gorm:"uniqueIndex" json:"content_id"`
+	BaseVersion int            `json:"base_version"` // Live version the draft was started from
+	Version     int            `json:"version"`      // Draft number, raised on every save and not reused after a discard; part of the content's version tag
+	Title       string         `json:"title"`
+	Slug        string         `json:"slug"`
+	Body        string         `json:"body"`
+	Blocks      datatypes.JSON `json:"blocks" swaggertype:"object"`
+	Type        string         `json:"type"`
+	Attributes  string         `json:"attributes"`
+	Language    string         `json:"language"`
+	PublishedAt *time.Time     `json:"published_at"`
+	UnpublishAt *time.Time     `json:"unpublish_at"`
+	CategoryIDs datatypes.JSON `json:"category_ids" swaggertype:"array,integer"`
+	Tags        datatypes.JSON `json:"tags" swaggertype:"array,string"` // Tag names
+	References  datatypes.JSON `json:"references" swaggertype:"object"` // Field name -> target IDs to set on publish
+	// Who last edited the draft and why; the message is kept with the version it is published as
+	UpdatedByID   uint      `json:"updated_by_id"`
+	ChangeMessage string    `json:"change_message"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Job statuses. PENDING jobs wait for RunAt; DEAD jobs ran out of attempts and stay
+// until retried or cancelled from the admin API.
+const (
+	JobPending   = "PENDING"
+	JobRunning   = "RUNNING"
+	JobSucceeded = "SUCCEEDED"
+	JobDead      = "DEAD"
+	JobCancelled = "CANCELLED"
+)
+
+// Job is a unit of background work processed by the queue workers
+type Job struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Type        string         `gorm:"index:idx_jobs_poll" json:"type"` // e.g. "webhook.deliver"
+	Status      string         `gorm:"index:idx_jobs_poll" json:"status"`
+	RunAt       time.Time      `gorm:"index:idx_jobs_poll" json:"run_at"`
+	Payload     datatypes.JSON `json:"payload" swaggertype:"object"`
+	Attempts    int            `json:"attempts"`
+	MaxAttempts int            `json:"max_attempts"`
+	LeasedUntil *time.Time     `json:"leased_until"` // A RUNNING job whose lease expired is picked up again
+	LastError   string         `json:"last_error"`
+	FinishedAt  *time.Time     `json:"finished_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Lock is a named lease used to coordinate server instances on SQLite; PostgreSQL uses
+// advisory locks instead (see database.TryLock)
+type Lock struct {
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
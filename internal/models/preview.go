@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type PreviewTokenRequest struct {
+	Version   int `json:"version" validate:"omitempty,min=1"`                 // Pin the preview to this version; the latest revision, draft included, when omitted
+	ExpiresIn int `json:"expires_in" validate:"omitempty,min=60,max=2592000"` // Seconds, default 24 hours
+}
+
+type PreviewTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	URL       string    `json:"url"`
+}
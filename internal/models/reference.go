@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	ReferenceTargetContent = "content"
+	ReferenceTargetMedia   = "media"
+
+	ReferenceOne  = "one"
+	ReferenceMany = "many"
+)
+
+// ContentReference is one entry of a reference field declared on the content's type
+type ContentReference struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ContentID  uint      `gorm:"index" json:"content_id"`
+	Field      string    `gorm:"index" json:"field"`
+	TargetType string    `json:"target_type"` // ReferenceTargetContent or ReferenceTargetMedia
+	TargetID   uint      `gorm:"index" json:"target_id"`
+	Position   int       `json:"position"` // Order within a "many" field
+	CreatedAt  time.Time `json:"created_at"`
+}
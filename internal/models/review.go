@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Review request and decision statuses
+const (
+	ReviewOpen             = "OPEN"
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+)
+
+// ReviewRequest asks the assigned reviewers to look at one version of a content item,
+// its working draft included. Approvals only count while the content is still at that
+// version, and for publishing the draft while the draft is unchanged.
+type ReviewRequest struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	ContentID     uint               `gorm:"index" json:"content_id"`
+	Version       int                `json:"version"`
+	DraftVersion  int                `json:"draft_version"` // Draft number at the time of the request; 0 without a draft
+	RequestedByID uint               `json:"requested_by_id"`
+	Note          string             `json:"note"`
+	Status        string             `json:"status"` // OPEN, APPROVED once everyone approved, CHANGES_REQUESTED
+	Assignments   []ReviewAssignment `json:"assignments"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// ReviewAssignment is one reviewer's decision on a review request
+type ReviewAssignment struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	ReviewRequestID uint       `gorm:"index" json:"review_request_id"`
+	ReviewerID      uint       `gorm:"index" json:"reviewer_id"`
+	Reviewer        User       `json:"reviewer"`
+	Decision        string     `json:"decision"` // Empty until decided, then APPROVED or CHANGES_REQUESTED
+	Note            string     `json:"note"`
+	DecidedAt       *time.Time `json:"decided_at"`
+}
+
+type ReviewRequestCreateRequest struct {
+	ReviewerIDs []uint `json:"reviewer_ids" validate:"required,min=1"`
+	Note        string `json:"note"`
+}
+
+type ReviewDecisionRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=APPROVED CHANGES_REQUESTED"`
+	Note     string `json:"note"`
+}
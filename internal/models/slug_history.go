@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ContentSlugHistory records a slug a content item used to have, so old URLs can be
+// redirected to the current one
+type ContentSlugHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ContentID uint      `gorm:"index" json:"content_id"`
+	Slug      string    `gorm:"index:idx_slug_history_lookup" json:"slug"`
+	Language  string    `gorm:"index:idx_slug_history_lookup" json:"language"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SlugRedirectResponse points an old slug at the content's current slug
+type SlugRedirectResponse struct {
+	Redirect bool   `json:"redirect"`
+	ID       uint   `json:"id"`
+	Slug     string `json:"slug"`
+	Language string `json:"language"`
+	Location string `json:"location"`
+}
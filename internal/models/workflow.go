@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Built-in workflow statuses. Transitions between them (and any custom statuses) are
+// configured as WorkflowTransition rows.
+const (
+	StatusDraft     = "DRAFT"
+	StatusInReview  = "IN_REVIEW"
+	StatusApproved  = "APPROVED"
+	StatusScheduled = "SCHEDULED"
+	StatusPublished = "PUBLISHED"
+	StatusArchived  = "ARCHIVED"
+)
+
+// WorkflowTransition allows moving content from one status to another for users holding
+// Permission. Event is the webhook event fired when it is taken.
+type WorkflowTransition struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Name       string `gorm:"uniqueIndex" json:"name"` // e.g. "submit", "approve"
+	FromStatus string `gorm:"uniqueIndex:idx_workflow_from_to" json:"from_status"`
+	ToStatus   string `gorm:"uniqueIndex:idx_workflow_from_to" json:"to_status"`
+	Permission string `json:"permission"`
+	Event      string `json:"event"` // Defaults to "content.<to_status lowercased>"
+	// RequiredApprovals is the number of reviewers that must have approved the content's
+	// current version before the transition can be taken
+	RequiredApprovals int       `json:"required_approvals"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ContentTransition is one entry of a content item's workflow history
+type ContentTransition struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ContentID  uint      `gorm:"index" json:"content_id"`
+	Transition string    `json:"transition"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	UserID     uint      `json:"user_id"` // 0 when taken by the scheduler
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WorkflowTransitionRequest struct {
+	Name       string `json:"name" validate:"required,min=2"`
+	FromStatus string `json:"from_status" validate:"required"`
+	ToStatus   string `json:"to_status" validate:"required"`
+	Permission string `json:"permission" validate:"required"`
+	Event      string `json:"event"`
+	// Approvals of the current version needed before the transition can be taken
+	RequiredApprovals int `json:"required_approvals" validate:"min=0"`
+}
+
+type ContentTransitionRequest struct {
+	Status      string     `json:"status" validate:"required"` // Target status
+	Comment     string     `json:"comment"`
+	PublishedAt *time.Time `json:"published_at"` // Publish date when moving to SCHEDULED
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"content-flow/internal/pkgs/apierrors"
+	"errors"
 	"strings"
 	"time"
 
@@ -22,6 +23,38 @@ func GenerateToken(userID uint, role string) (string, error) {
 	return token.SignedString(SecretKey)
 }
 
+// ParseToken validates a signed token (with or without the "Bearer " prefix) and returns its claims.
+// Purpose-bound tokens such as preview links are rejected; they do not identify a user.
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := claims["purpose"]; ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+func parseClaims(tokenString string) (jwt.MapClaims, error) {
+	tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return SecretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
 func Protected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
@@ -29,27 +62,68 @@ func Protected() fiber.Handler {
 			return apierrors.New(fiber.StatusUnauthorized, "Missing Authorization Header")
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, apierrors.New(fiber.StatusUnauthorized, "Unexpected signing method")
-			}
-			return SecretKey, nil
-		})
+		claims, err := ParseToken(authHeader)
+		if err != nil {
+			return apierrors.New(fiber.StatusUnauthorized, "Invalid or Expired Token")
+		}
+
+		// Store user info in locals
+		c.Locals("user_id", claims["user_id"])
+		c.Locals("role", claims["role"])
 
-		if err != nil || !token.Valid {
+		return c.Next()
+	}
+}
+
+// ProtectedSocket is Protected for WebSocket handshakes. Browsers cannot set headers on
+// those, so the token may also be passed in the token query parameter.
+func ProtectedSocket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get("Authorization")
+		if token == "" {
+			token = c.Query("token")
+		}
+		if token == "" {
+			return apierrors.New(fiber.StatusUnauthorized, "Missing Authorization Header or token")
+		}
+
+		claims, err := ParseToken(token)
+		if err != nil {
 			return apierrors.New(fiber.StatusUnauthorized, "Invalid or Expired Token")
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return apierrors.Internal("Invalid Token Claims")
+		c.Locals("user_id", claims["user_id"])
+		c.Locals("role", claims["role"])
+
+		return c.Next()
+	}
+}
+
+// Optional behaves like Protected when an Authorization header is sent, and lets
+// anonymous requests through otherwise (user_id stays unset).
+func Optional() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if authHeader == "" {
+			return c.Next()
+		}
+
+		claims, err := ParseToken(authHeader)
+		if err != nil {
+			return apierrors.New(fiber.StatusUnauthorized, "Invalid or Expired Token")
 		}
 
-		// Store user info in locals
 		c.Locals("user_id", claims["user_id"])
 		c.Locals("role", claims["role"])
 
 		return c.Next()
 	}
 }
+
+// UserID returns the authenticated user's ID, or 0 for anonymous requests
+func UserID(c *fiber.Ctx) uint {
+	if id, ok := c.Locals("user_id").(float64); ok {
+		return uint(id)
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PreviewPurpose marks tokens that only unlock one content item for reading. They carry
+// no user, and ParseToken refuses them so they never authenticate a request.
+const PreviewPurpose = "preview"
+
+// PreviewClaims is what a preview token grants: reading ContentID, pinned to Version when
+// it is non-zero
+type PreviewClaims struct {
+	ContentID uint
+	Version   int
+	ExpiresAt time.Time
+}
+
+// GeneratePreviewToken signs a preview token for contentID that expires after ttl
+func GeneratePreviewToken(contentID uint, version int, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := jwt.MapClaims{
+		"purpose":    PreviewPurpose,
+		"content_id": contentID,
+		"exp":        expiresAt.Unix(),
+	}
+	if version > 0 {
+		claims["version"] = version
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(SecretKey)
+	return token, expiresAt, err
+}
+
+// ParsePreviewToken validates a token minted by GeneratePreviewToken
+func ParsePreviewToken(tokenString string) (*PreviewClaims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims["purpose"] != PreviewPurpose {
+		return nil, errors.New("not a preview token")
+	}
+
+	contentID, ok := claims["content_id"].(float64)
+	if !ok || contentID <= 0 {
+		return nil, errors.New("invalid token claims")
+	}
+	preview := &PreviewClaims{ContentID: uint(contentID)}
+	if version, ok := claims["version"].(float64); ok {
+		preview.Version = int(version)
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		preview.ExpiresAt = exp.Time
+	}
+	return preview, nil
+}
@@ -8,34 +8,41 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// HasPermission reports whether the user's role grants permSlug. Admins have every permission.
+func HasPermission(userID uint, permSlug string) (bool, error) {
+	// Fetch User with Role and Permissions
+	var user models.User
+	if err := database.DB.Preload("Role.Permissions").First(&user, userID).Error; err != nil {
+		return false, err
+	}
+
+	// Check if user has admin role (bypass check)
+	if user.Role.Name == "Admin" {
+		return true, nil
+	}
+
+	// Check if role has the permission
+	for _, p := range user.Role.Permissions {
+		if p.Slug == permSlug {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // RequirePermission checks if the authenticated user has the specified permission
 func RequirePermission(permSlug string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userID := c.Locals("user_id")
-		if userID == nil {
+		userID := UserID(c)
+		if userID == 0 {
 			return apierrors.New(fiber.StatusUnauthorized, "Unauthorized")
 		}
 
-		// Fetch User with Role and Permissions
-		var user models.User
-		if err := database.DB.Preload("Role.Permissions").First(&user, userID).Error; err != nil {
+		hasPerm, err := HasPermission(userID, permSlug)
+		if err != nil {
 			return apierrors.New(fiber.StatusUnauthorized, "User not found")
 		}
 
-		// Check if user has admin role (bypass check)
-		if user.Role.Name == "Admin" {
-			return c.Next()
-		}
-
-		// Check if role has the permission
-		hasPerm := false
-		for _, p := range user.Role.Permissions {
-			if p.Slug == permSlug {
-				hasPerm = true
-				break
-			}
-		}
-
 		if !hasPerm {
 			return apierrors.New(fiber.StatusForbidden, "Forbidden: Missing permission "+permSlug)
 		}
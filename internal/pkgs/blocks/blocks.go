@@ -0,0 +1,153 @@
+package blocks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"content-flow/internal/pkgs/jsonschema"
+	"content-flow/internal/pkgs/validator"
+)
+
+// Block is a single entry of Content.Blocks, e.g.
+// {"id": "b1", "type": "heading", "data": {"text": "Intro", "level": 2}}
+type Block struct {
+	ID   string          `json:"id,omitempty"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// Definition describes a block type and the JSON Schema its data must follow.
+type Definition struct {
+	Type        string          `json:"type"`
+	Label       string          `json:"label"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema" swaggertype:"object"`
+	BuiltIn     bool            `json:"built_in"`
+}
+
+var builtIns = []Definition{
+	{
+		Type:        "paragraph",
+		Label:       "Paragraph",
+		Description: "A block of plain text",
+		Schema:      json.RawMessage(`{"type":"object","required":["text"],"properties":{"text":{"type":"string"}}}`),
+	},
+	{
+		Type:        "heading",
+		Label:       "Heading",
+		Description: "A section heading (levels 1-6)",
+		Schema:      json.RawMessage(`{"type":"object","required":["text","level"],"properties":{"text":{"type":"string","minLength":1},"level":{"type":"integer","minimum":1,"maximum":6}}}`),
+	},
+	{
+		Type:        "image",
+		Label:       "Image",
+		Description: "An image, usually an uploaded media file",
+		Schema:      json.RawMessage(`{"type":"object","required":["url"],"properties":{"url":{"type":"string","format":"link"},"media_id":{"type":"integer","minimum":1},"alt":{"type":"string"},"caption":{"type":"string"}}}`),
+	},
+	{
+		Type:        "quote",
+		Label:       "Quote",
+		Description: "A quotation with optional attribution",
+		Schema:      json.RawMessage(`{"type":"object","required":["text"],"properties":{"text":{"type":"string","minLength":1},"caption":{"type":"string"}}}`),
+	},
+	{
+		Type:        "embed",
+		Label:       "Embed",
+		Description: "External media such as a video or a post",
+		Schema:      json.RawMessage(`{"type":"object","required":["url"],"properties":{"url":{"type":"string","format":"link"},"service":{"type":"string"},"caption":{"type":"string"}}}`),
+	},
+	{
+		Type:        "list",
+		Label:       "List",
+		Description: "An ordered or unordered list",
+		Schema:      json.RawMessage(`{"type":"object","required":["items"],"properties":{"style":{"type":"string","enum":["ordered","unordered"]},"items":{"type":"array","minItems":1,"items":{"type":"string"}}}}`),
+	},
+	{
+		Type:        "code",
+		Label:       "Code",
+		Description: "A code snippet",
+		Schema:      json.RawMessage(`{"type":"object","required":["code"],"properties":{"code":{"type":"string"},"language":{"type":"string"}}}`),
+	},
+}
+
+// BuiltIns returns the block types that are always available.
+func BuiltIns() []Definition {
+	defs := make([]Definition, len(builtIns))
+	for i, d := range builtIns {
+		d.BuiltIn = true
+		defs[i] = d
+	}
+	return defs
+}
+
+// IsBuiltIn reports whether blockType is reserved by a built-in definition.
+func IsBuiltIn(blockType string) bool {
+	for _, d := range builtIns {
+		if d.Type == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse decodes a Blocks payload. An empty payload or JSON null yields no blocks.
+func Parse(raw []byte) ([]Block, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []Block
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Validate checks a Blocks payload against the given definitions (keyed by type) and
+// reports errors with paths such as "blocks[2].data.level".
+func Validate(raw []byte, defs map[string]Definition) []*validator.ErrorResponse {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return []*validator.ErrorResponse{{Field: "blocks", Message: "Must be an array of blocks"}}
+	}
+
+	var errs []*validator.ErrorResponse
+	for i, item := range items {
+		path := fmt.Sprintf("blocks[%d]", i)
+
+		var block Block
+		if err := json.Unmarshal(item, &block); err != nil {
+			errs = append(errs, &validator.ErrorResponse{Field: path, Message: "Must be an object with type and data"})
+			continue
+		}
+		if block.Type == "" {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".type", Message: "This field is required"})
+			continue
+		}
+
+		def, ok := defs[block.Type]
+		if !ok {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".type", Message: "Unknown block type " + block.Type})
+			continue
+		}
+		if len(def.Schema) == 0 {
+			continue
+		}
+
+		schema, err := jsonschema.Parse(def.Schema)
+		if err != nil {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".type", Message: "Block type " + block.Type + " has an invalid schema"})
+			continue
+		}
+
+		data := block.Data
+		if len(data) == 0 {
+			data = json.RawMessage("{}")
+		}
+		errs = append(errs, schema.ValidateJSON(data, path+".data")...)
+	}
+	return errs
+}
@@ -0,0 +1,174 @@
+package diff
+
+import (
+	"bytes"
+	"content-flow/internal/pkgs/blocks"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Block change operations
+const (
+	BlockAdded   = "added"
+	BlockRemoved = "removed"
+	BlockChanged = "changed"
+)
+
+// BlockChange describes one block that differs between two block lists. Indexes are
+// positions in the old and new list; Data lists the field changes of a changed block.
+type BlockChange struct {
+	Op       string          `json:"op"`
+	ID       string          `json:"id,omitempty"`
+	Type     string          `json:"type"`
+	OldType  string          `json:"old_type,omitempty"`
+	OldIndex *int            `json:"old_index,omitempty"`
+	NewIndex *int            `json:"new_index,omitempty"`
+	Old      json.RawMessage `json:"old,omitempty" swaggertype:"object"`
+	New      json.RawMessage `json:"new,omitempty" swaggertype:"object"`
+	Data     []Change        `json:"data,omitempty"`
+}
+
+// Blocks compares two JSON block lists. Blocks with an id are matched by id wherever they
+// moved; blocks without one are aligned by content, and a removed block directly
+// replaced by one of the same type is reported as changed.
+func Blocks(a, b []byte) ([]BlockChange, error) {
+	var old, cur []blocks.Block
+	if err := unmarshalBlocks(a, &old); err != nil {
+		return nil, err
+	}
+	if err := unmarshalBlocks(b, &cur); err != nil {
+		return nil, err
+	}
+
+	changes := []BlockChange{}
+	oldByID := map[string]int{}
+	for i, blk := range old {
+		if blk.ID != "" {
+			oldByID[blk.ID] = i
+		}
+	}
+	newByID := map[string]int{}
+	for j, blk := range cur {
+		if blk.ID == "" {
+			continue
+		}
+		newByID[blk.ID] = j
+		if i, ok := oldByID[blk.ID]; ok {
+			if change, differs := compareBlocks(old, cur, i, j); differs {
+				changes = append(changes, change)
+			}
+		} else {
+			changes = append(changes, added(cur, j))
+		}
+	}
+	for i, blk := range old {
+		if _, ok := newByID[blk.ID]; blk.ID != "" && !ok {
+			changes = append(changes, removed(old, i))
+		}
+	}
+
+	// Blocks without an id are aligned on their content; each run of removals and
+	// insertions between unchanged blocks is paired up in order
+	oldKeys, oldIdx := anonymous(old)
+	newKeys, newIdx := anonymous(cur)
+	var dels, ins []int
+	flush := func() {
+		for len(dels) > 0 && len(ins) > 0 && old[dels[0]].Type == cur[ins[0]].Type {
+			change, _ := compareBlocks(old, cur, dels[0], ins[0])
+			changes = append(changes, change)
+			dels, ins = dels[1:], ins[1:]
+		}
+		for _, i := range dels {
+			changes = append(changes, removed(old, i))
+		}
+		for _, j := range ins {
+			changes = append(changes, added(cur, j))
+		}
+		dels, ins = nil, nil
+	}
+	i, j := 0, 0
+	for _, e := range myers(oldKeys, newKeys) {
+		switch e.op {
+		case Equal:
+			flush()
+			i++
+			j++
+		case Delete:
+			dels = append(dels, oldIdx[i])
+			i++
+		case Insert:
+			ins = append(ins, newIdx[j])
+			j++
+		}
+	}
+	flush()
+
+	sort.SliceStable(changes, func(x, y int) bool {
+		return position(changes[x]) < position(changes[y])
+	})
+	return changes, nil
+}
+
+func unmarshalBlocks(data []byte, v *[]blocks.Block) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// anonymous returns a content key and the index of every block without an id
+func anonymous(list []blocks.Block) ([]string, []int) {
+	var keys []string
+	var idx []int
+	for i, blk := range list {
+		if blk.ID != "" {
+			continue
+		}
+		var data interface{}
+		_ = json.Unmarshal(blk.Data, &data)
+		canonical, _ := json.Marshal(data)
+		keys = append(keys, blk.Type+":"+string(canonical))
+		idx = append(idx, i)
+	}
+	return keys, idx
+}
+
+func compareBlocks(old, cur []blocks.Block, i, j int) (BlockChange, bool) {
+	var a, b interface{}
+	_ = json.Unmarshal(old[i].Data, &a)
+	_ = json.Unmarshal(cur[j].Data, &b)
+	if old[i].Type == cur[j].Type && reflect.DeepEqual(a, b) {
+		return BlockChange{}, false
+	}
+
+	change := BlockChange{
+		Op:       BlockChanged,
+		ID:       cur[j].ID,
+		Type:     cur[j].Type,
+		OldIndex: &i,
+		NewIndex: &j,
+		Data:     Values(a, b),
+	}
+	if old[i].Type != cur[j].Type {
+		change.OldType = old[i].Type
+	}
+	return change, true
+}
+
+func added(list []blocks.Block, j int) BlockChange {
+	return BlockChange{Op: BlockAdded, ID: list[j].ID, Type: list[j].Type, NewIndex: &j, New: list[j].Data}
+}
+
+func removed(list []blocks.Block, i int) BlockChange {
+	return BlockChange{Op: BlockRemoved, ID: list[i].ID, Type: list[i].Type, OldIndex: &i, Old: list[i].Data}
+}
+
+// position orders changes by where they appear in the new list, removed blocks at their
+// old position
+func position(c BlockChange) int {
+	if c.NewIndex != nil {
+		return *c.NewIndex
+	}
+	return *c.OldIndex
+}
@@ -0,0 +1,119 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Structural change operations
+const (
+	Add     = "add"
+	Remove  = "remove"
+	Replace = "replace"
+)
+
+// Change is a difference at one path of two JSON documents. Path uses dots for object
+// keys and [i] for array indexes and is empty for the document root.
+type Change struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// JSON compares two JSON documents structurally. Empty input is treated as null.
+func JSON(a, b []byte) ([]Change, error) {
+	var av, bv interface{}
+	if err := unmarshal(a, &av); err != nil {
+		return nil, err
+	}
+	if err := unmarshal(b, &bv); err != nil {
+		return nil, err
+	}
+	return Values(av, bv), nil
+}
+
+// Values compares two decoded JSON values
+func Values(a, b interface{}) []Change {
+	changes := []Change{}
+	compare("", a, b, &changes)
+	return changes
+}
+
+func unmarshal(data []byte, v *interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+func compare(path string, a, b interface{}, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			compareObjects(path, av, bv, changes)
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			compareArrays(path, av, bv, changes)
+			return
+		}
+	}
+
+	switch {
+	case reflect.DeepEqual(a, b):
+	case a == nil:
+		*changes = append(*changes, Change{Path: path, Op: Add, New: b})
+	case b == nil:
+		*changes = append(*changes, Change{Path: path, Op: Remove, Old: a})
+	default:
+		*changes = append(*changes, Change{Path: path, Op: Replace, Old: a, New: b})
+	}
+}
+
+func compareObjects(path string, a, b map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		child := k
+		if path != "" {
+			child = path + "." + k
+		}
+		switch {
+		case !inA:
+			*changes = append(*changes, Change{Path: child, Op: Add, New: bv})
+		case !inB:
+			*changes = append(*changes, Change{Path: child, Op: Remove, Old: av})
+		default:
+			compare(child, av, bv, changes)
+		}
+	}
+}
+
+func compareArrays(path string, a, b []interface{}, changes *[]Change) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		child := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, Change{Path: child, Op: Add, New: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, Change{Path: child, Op: Remove, Old: a[i]})
+		default:
+			compare(child, a[i], b[i], changes)
+		}
+	}
+}
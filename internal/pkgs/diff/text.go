@@ -0,0 +1,175 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Text diff operations
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// maxEdits bounds the work spent on one diff. Inputs that differ by more are reported
+// as a single delete plus insert.
+const maxEdits = 2000
+
+// Op is a run of consecutive tokens with the same operation
+type Op struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines diffs a and b line by line
+func Lines(a, b string) []Op {
+	return merge(myers(strings.Split(a, "\n"), strings.Split(b, "\n")), "\n")
+}
+
+// Words diffs a and b word by word; whitespace runs are tokens of their own so joining
+// the Text of the ops of either side gives back the original
+func Words(a, b string) []Op {
+	return merge(myers(words(a), words(b)), "")
+}
+
+func words(s string) []string {
+	var tokens []string
+	start, space := 0, false
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != space {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// edit is a single token operation produced by myers
+type edit struct {
+	op    string
+	token string
+}
+
+// merge joins consecutive edits of the same kind
+func merge(edits []edit, sep string) []Op {
+	ops := []Op{}
+	for _, e := range edits {
+		if n := len(ops); n > 0 && ops[n-1].Op == e.op {
+			ops[n-1].Text += sep + e.token
+			continue
+		}
+		ops = append(ops, Op{Op: e.op, Text: e.token})
+	}
+	return ops
+}
+
+// myers computes a shortest edit script from a to b (Myers, "An O(ND) Difference
+// Algorithm"). Only the diagonals reachable in each round are kept, so memory is O(D²).
+func myers(a, b []string) []edit {
+	// Common prefix and suffix need no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, t := range a[:prefix] {
+		edits = append(edits, edit{Equal, t})
+	}
+	edits = append(edits, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		edits = append(edits, edit{Equal, t})
+	}
+	return edits
+}
+
+func middle(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v for diagonals -(d+1)..d+1 at the start of round d
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+func backtrack(trace [][]int, a, b []string) []edit {
+	var reversed []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, edit{Insert, b[y-1]})
+			} else {
+				reversed = append(reversed, edit{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, t := range a {
+		edits = append(edits, edit{Delete, t})
+	}
+	for _, t := range b {
+		edits = append(edits, edit{Insert, t})
+	}
+	return edits
+}
@@ -0,0 +1,282 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"content-flow/internal/pkgs/validator"
+)
+
+// Schema is the subset of JSON Schema (draft-07) supported by the content type registry.
+// Unknown keywords are ignored so schemas authored for other tools still load.
+type Schema struct {
+	Type                 TypeList           `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// TypeList accepts both `"type": "string"` and `"type": ["string", "null"]`.
+type TypeList []string
+
+func (t *TypeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = TypeList{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return errors.New("type must be a string or an array of strings")
+	}
+	*t = multi
+	return nil
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// Parse decodes a schema document and checks that it only uses supported values.
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	for _, t := range s.Type {
+		if !knownTypes[t] {
+			return fmt.Errorf("invalid schema at %q: unknown type %q", path, t)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema at %q: bad pattern: %w", path, err)
+		}
+		s.pattern = re
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("invalid schema at %q: property %q is null", path, name)
+		}
+		if err := prop.compile(joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateJSON decodes raw and validates it, prefixing every error field with root.
+func (s *Schema) ValidateJSON(raw []byte, root string) []*validator.ErrorResponse {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return []*validator.ErrorResponse{{Field: root, Message: "Must be valid JSON"}}
+	}
+	return s.Validate(value, root)
+}
+
+// Validate checks an already-decoded JSON value (as produced by encoding/json).
+func (s *Schema) Validate(value interface{}, path string) []*validator.ErrorResponse {
+	var errs []*validator.ErrorResponse
+	fail := func(msg string) {
+		errs = append(errs, &validator.ErrorResponse{Field: path, Message: msg})
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		fail("Must be of type " + strings.Join(s.Type, " or "))
+		return errs
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		opts := make([]string, 0, len(s.Enum))
+		for _, e := range s.Enum {
+			opts = append(opts, fmt.Sprint(e))
+		}
+		fail("Must be one of: " + strings.Join(opts, ", "))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, &validator.ErrorResponse{Field: joinPath(path, name), Message: "This field is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs = append(errs, &validator.ErrorResponse{Field: joinPath(path, k), Message: "Unknown field"})
+				}
+				continue
+			}
+			errs = append(errs, prop.Validate(v[k], joinPath(path, k))...)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("Must contain at least " + strconv.Itoa(*s.MinItems) + " items")
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("Must contain at most " + strconv.Itoa(*s.MaxItems) + " items")
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.Validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("Value must be at least " + strconv.Itoa(*s.MinLength) + " characters")
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("Value must be at most " + strconv.Itoa(*s.MaxLength) + " characters")
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("Must match pattern " + s.Pattern)
+		}
+		if s.Format != "" && !validFormat(s.Format, v) {
+			fail("Invalid " + s.Format + " format")
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("Value must be at least " + formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("Value must be at most " + formatNumber(*s.Maximum))
+		}
+		if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+			fail("Value must be greater than " + formatNumber(*s.ExclusiveMinimum))
+		}
+		if s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum {
+			fail("Value must be less than " + formatNumber(*s.ExclusiveMaximum))
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	for _, t := range s.Type {
+		switch t {
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := value.(float64); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "null":
+			if value == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func validFormat(format, v string) bool {
+	switch format {
+	case "email":
+		_, err := mail.ParseAddress(v)
+		return err == nil
+	case "uri", "url":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	case "link":
+		// Something safe to put in an href or src: an http(s) URL or a relative one
+		u, err := url.Parse(v)
+		if err != nil || v == "" {
+			return false
+		}
+		return u.Scheme == "" || (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	default:
+		// Unknown formats are annotations only, as in the spec.
+		return true
+	}
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,118 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// Cursor identifies the last item of a page. Lists ordered by ID only (tags) leave CreatedAt zero.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// wireCursor is the JSON form of Cursor inside the token
+type wireCursor struct {
+	T  *time.Time `json:"t,omitempty"`
+	ID uint       `json:"id"`
+}
+
+// Encode returns the opaque token handed to clients as next_cursor
+func (c Cursor) Encode() string {
+	w := wireCursor{ID: c.ID}
+	if !c.CreatedAt.IsZero() {
+		w.T = &c.CreatedAt
+	}
+	raw, _ := json.Marshal(w)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode parses a token produced by Encode; an empty token means "first page"
+func Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var w wireCursor
+	if err := json.Unmarshal(raw, &w); err != nil || w.ID == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	c := &Cursor{ID: w.ID}
+	if w.T != nil {
+		c.CreatedAt = *w.T
+	}
+	return c, nil
+}
+
+// Params selects between offset (page/limit) and keyset (cursor) pagination
+type Params struct {
+	Keyset    bool
+	Cursor    *Cursor
+	Page      int
+	Limit     int
+	SkipTotal bool // Offset mode counts by default, keyset mode only when asked
+}
+
+// Normalize applies defaults and bounds to Page and Limit
+func (p *Params) Normalize() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+}
+
+// Page describes the result of a paginated query
+type Page struct {
+	Total      int64
+	HasTotal   bool
+	NextCursor string
+}
+
+// Newest orders by created_at desc, id desc and, when a cursor is given, continues
+// strictly after it. One extra row is fetched so Trim can tell whether more exist.
+func Newest(table string, cursor *Cursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor != nil {
+			db = db.Where("("+table+".created_at < ? OR ("+table+".created_at = ? AND "+table+".id < ?))",
+				cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+		return db.Order(table + ".created_at desc").Order(table + ".id desc").Limit(limit + 1)
+	}
+}
+
+// ByID orders by id asc for tables without timestamps; see Newest.
+func ByID(table string, cursor *Cursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor != nil {
+			db = db.Where(table+".id > ?", cursor.ID)
+		}
+		return db.Order(table + ".id asc").Limit(limit + 1)
+	}
+}
+
+// Trim drops the extra row fetched by Newest/ByID and returns the cursor for the next
+// page, or "" when this was the last one.
+func Trim[T any](items []T, limit int, cursorOf func(T) Cursor) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, cursorOf(items[len(items)-1]).Encode()
+}
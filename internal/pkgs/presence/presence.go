@@ -0,0 +1,297 @@
+package presence
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A room per content item tracks who has it open, who holds its edit lock, and relays
+// events such as saves to everyone in it. Rooms live in memory, so clients only see
+// the others connected to the same instance. Locks are soft: they tell editors someone
+// else is working on an item, but writes are not refused; version checks (If-Match)
+// catch the edits that still collide.
+
+// Member states
+const (
+	Viewing = "viewing"
+	Editing = "editing"
+)
+
+// LockTTL is how long an edit lock is held without being renewed
+const LockTTL = 30 * time.Second
+
+// sendBuffer is how many events may wait for a slow client before it is dropped
+const sendBuffer = 32
+
+// User identifies the person behind a connection
+type User struct {
+	ID       uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Member is a user in a room. A user connected more than once is listed once, as
+// editing when any of the connections is, since the first of them joined.
+type Member struct {
+	User
+	State string    `json:"state"`
+	Since time.Time `json:"since"`
+}
+
+// Lock is the edit lock of a content item
+type Lock struct {
+	User
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Events sent to clients
+type (
+	// StateEvent is sent to a client when it joins
+	StateEvent struct {
+		Type    string   `json:"type"` // "state"
+		Members []Member `json:"members"`
+		Lock    *Lock    `json:"lock"`
+	}
+	// PresenceEvent is sent when someone joins, leaves or changes state
+	PresenceEvent struct {
+		Type    string   `json:"type"` // "presence"
+		Members []Member `json:"members"`
+	}
+	// LockEvent is sent when the lock is taken, renewed, released or expires; Lock is
+	// nil while the item is free
+	LockEvent struct {
+		Type string `json:"type"` // "lock"
+		Lock *Lock  `json:"lock"`
+	}
+)
+
+// Client is one connection to a room
+type Client struct {
+	User      User
+	contentID uint
+	state     string
+	since     time.Time
+	send      chan []byte
+	closed    bool // send is closed
+	left      bool // removed from the room
+}
+
+type room struct {
+	clients map[*Client]struct{}
+	lock    *Lock
+	holder  *Client
+	expiry  *time.Timer
+}
+
+var (
+	mu    sync.Mutex
+	rooms = map[uint]*room{}
+)
+
+// Join adds a viewing client for user to the room of contentID. The client receives a
+// StateEvent first and the room's events after it, on Messages, until it leaves.
+func Join(contentID uint, user User) *Client {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r := rooms[contentID]
+	if r == nil {
+		r = &room{clients: map[*Client]struct{}{}}
+		rooms[contentID] = r
+	}
+	client := &Client{
+		User:      user,
+		contentID: contentID,
+		state:     Viewing,
+		since:     time.Now(),
+		send:      make(chan []byte, sendBuffer),
+	}
+	r.clients[client] = struct{}{}
+
+	client.deliver(encode(StateEvent{Type: "state", Members: r.members(), Lock: r.lock}))
+	r.broadcastPresence()
+	return client
+}
+
+// Messages returns the encoded events for the client. The channel is closed when the
+// client leaves or is dropped for not keeping up.
+func (c *Client) Messages() <-chan []byte {
+	return c.send
+}
+
+// Send queues an event for this client only
+func (c *Client) Send(event interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	c.deliver(encode(event))
+}
+
+// SetState marks the client as Viewing or Editing
+func (c *Client) SetState(state string) {
+	mu.Lock()
+	defer mu.Unlock()
+	r := rooms[c.contentID]
+	if r == nil || c.left || c.state == state {
+		return
+	}
+	c.state = state
+	r.broadcastPresence()
+}
+
+// Lock takes or renews the edit lock for LockTTL. Another connection of the same user
+// takes the lock over. When someone else holds it, it is returned with ok false.
+func (c *Client) Lock() (lock *Lock, ok bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	r := rooms[c.contentID]
+	if r == nil || c.left {
+		return nil, false
+	}
+	if r.lock != nil && r.lock.ID != c.User.ID {
+		return r.lock, false
+	}
+
+	if r.expiry != nil {
+		r.expiry.Stop()
+	}
+	lock = &Lock{User: c.User, ExpiresAt: time.Now().Add(LockTTL)}
+	r.lock, r.holder = lock, c
+	r.expiry = time.AfterFunc(LockTTL, func() { expire(c.contentID, lock) })
+	r.broadcast(LockEvent{Type: "lock", Lock: lock})
+	return lock, true
+}
+
+// Unlock releases the edit lock if this client holds it
+func (c *Client) Unlock() {
+	mu.Lock()
+	defer mu.Unlock()
+	if r := rooms[c.contentID]; r != nil && r.holder == c {
+		r.release()
+	}
+}
+
+// Leave removes the client from its room, releasing the lock it holds
+func (c *Client) Leave() {
+	mu.Lock()
+	defer mu.Unlock()
+	c.remove()
+}
+
+// Notify sends an event to everyone in the room of contentID
+func Notify(contentID uint, event interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r := rooms[contentID]; r != nil {
+		r.broadcast(event)
+	}
+}
+
+// expire releases lock once its TTL passed, unless it was renewed or released since
+func expire(contentID uint, lock *Lock) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r := rooms[contentID]; r != nil && r.lock == lock {
+		r.release()
+	}
+}
+
+// The functions below expect mu to be held
+
+func (c *Client) deliver(msg []byte) {
+	if c.closed || msg == nil {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		// Too far behind. The connection is closed when its channel is, and leaves then.
+		c.closed = true
+		close(c.send)
+	}
+}
+
+func (c *Client) remove() {
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+	if c.left {
+		return
+	}
+	c.left = true
+
+	r := rooms[c.contentID]
+	if r == nil {
+		return
+	}
+	delete(r.clients, c)
+	if len(r.clients) == 0 {
+		if r.expiry != nil {
+			r.expiry.Stop()
+		}
+		delete(rooms, c.contentID)
+		return
+	}
+	if r.holder == c {
+		r.release()
+	}
+	r.broadcastPresence()
+}
+
+func (r *room) release() {
+	if r.expiry != nil {
+		r.expiry.Stop()
+	}
+	r.lock, r.holder, r.expiry = nil, nil, nil
+	r.broadcast(LockEvent{Type: "lock"})
+}
+
+func (r *room) broadcastPresence() {
+	r.broadcast(PresenceEvent{Type: "presence", Members: r.members()})
+}
+
+func (r *room) broadcast(event interface{}) {
+	msg := encode(event)
+	for client := range r.clients {
+		client.deliver(msg)
+	}
+}
+
+// members lists the users in the room in the order they arrived
+func (r *room) members() []Member {
+	byUser := map[uint]*Member{}
+	for client := range r.clients {
+		m := byUser[client.User.ID]
+		if m == nil {
+			byUser[client.User.ID] = &Member{User: client.User, State: client.state, Since: client.since}
+			continue
+		}
+		if client.state == Editing {
+			m.State = Editing
+		}
+		if client.since.Before(m.Since) {
+			m.Since = client.since
+		}
+	}
+
+	members := make([]Member, 0, len(byUser))
+	for _, m := range byUser {
+		members = append(members, *m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].Since.Equal(members[j].Since) {
+			return members[i].Since.Before(members[j].Since)
+		}
+		return members[i].ID < members[j].ID
+	})
+	return members
+}
+
+func encode(event interface{}) []byte {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+	return msg
+}
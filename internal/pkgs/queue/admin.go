@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/pagination"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotRetryable   = errors.New("only DEAD or CANCELLED jobs can be retried")
+	ErrNotCancellable = errors.New("only PENDING jobs can be cancelled")
+	// ErrRecurring is returned for jobs of recurring types: the next run is always queued
+	// already, so a retry would start a second chain and a cancel would stop the type
+	ErrRecurring = errors.New("recurring jobs are rescheduled automatically and cannot be retried or cancelled")
+)
+
+// Filter narrows List; empty fields match everything
+type Filter struct {
+	Type   string
+	Status string
+}
+
+// List returns jobs newest first
+func List(filter Filter, p pagination.Params) ([]models.Job, pagination.Page, error) {
+	var jobs []models.Job
+	var page pagination.Page
+	p.Normalize()
+
+	query := database.DB.Model(&models.Job{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if !p.SkipTotal {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, page, err
+		}
+		page.HasTotal = true
+	}
+
+	if p.Keyset {
+		if err := query.Scopes(pagination.Newest("jobs", p.Cursor, p.Limit)).Find(&jobs).Error; err != nil {
+			return nil, page, err
+		}
+		jobs, page.NextCursor = pagination.Trim(jobs, p.Limit, func(j models.Job) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: j.CreatedAt, ID: j.ID}
+		})
+		return jobs, page, nil
+	}
+
+	err := query.Order("created_at desc").Order("id desc").
+		Offset((p.Page - 1) * p.Limit).Limit(p.Limit).
+		Find(&jobs).Error
+	return jobs, page, err
+}
+
+func Get(id uint) (*models.Job, error) {
+	var job models.Job
+	if err := database.DB.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Retry queues a dead or cancelled job again with a fresh set of attempts
+func Retry(id uint) (*models.Job, error) {
+	return transition(id, []string{models.JobDead, models.JobCancelled}, ErrNotRetryable, map[string]interface{}{
+		"status":      models.JobPending,
+		"run_at":      time.Now(),
+		"attempts":    0,
+		"finished_at": nil,
+	})
+}
+
+// Cancel stops a pending job, including one waiting for a retry, from running
+func Cancel(id uint) (*models.Job, error) {
+	return transition(id, []string{models.JobPending}, ErrNotCancellable, map[string]interface{}{
+		"status":      models.JobCancelled,
+		"finished_at": time.Now(),
+	})
+}
+
+// transition updates a job only while it is in one of the from statuses
+func transition(id uint, from []string, notAllowed error, updates map[string]interface{}) (*models.Job, error) {
+	job, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := lookup(job.Type); ok && t.opts.Every > 0 {
+		return nil, ErrRecurring
+	}
+	updates["updated_at"] = time.Now()
+	result := database.DB.Model(&models.Job{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, notAllowed
+	}
+	return Get(id)
+}
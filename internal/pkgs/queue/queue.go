@@ -0,0 +1,261 @@
+package queue
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// The queue keeps jobs in the jobs table so work survives restarts. Workers lease a
+// job by flipping it to RUNNING with a LeasedUntil deadline; a job whose worker died is
+// leased again once the deadline passes. Failed jobs are retried with exponential
+// backoff and moved to DEAD after MaxAttempts, where they wait for an admin to retry
+// or cancel them.
+
+// Handler processes one job. Returning an error schedules a retry; ctx is cancelled
+// when the lease runs out.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Options configure a job type
+type Options struct {
+	Concurrency int           // Jobs of this type processed at once by this instance (default 1)
+	Lease       time.Duration // How long a worker owns a job before it is handed out again (default 1m)
+	MaxAttempts int           // Attempts before a job is dead-lettered (default 5)
+	// Every makes the type recurring: one job is kept queued and the next run is
+	// enqueued Every after the previous one finishes
+	Every time.Duration
+}
+
+const (
+	defaultLease       = time.Minute
+	defaultMaxAttempts = 5
+	pollInterval       = time.Second
+	backoffBase        = 10 * time.Second
+	backoffMax         = time.Hour
+)
+
+type jobType struct {
+	handler Handler
+	opts    Options
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]jobType{}
+)
+
+// Register installs the handler for a job type. Call before Start.
+func Register(name string, opts Options, handler Handler) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	mu.Lock()
+	registry[name] = jobType{handler: handler, opts: opts}
+	mu.Unlock()
+}
+
+func lookup(name string) (jobType, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Enqueue adds a job that becomes due at runAt (now when zero). Pass a transaction as
+// db to enqueue atomically with other writes.
+func Enqueue(db *gorm.DB, name string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	maxAttempts := defaultMaxAttempts
+	if t, ok := lookup(name); ok {
+		maxAttempts = t.opts.MaxAttempts
+	}
+	job := &models.Job{
+		Type:        name,
+		Status:      models.JobPending,
+		RunAt:       runAt,
+		Payload:     datatypes.JSON(raw),
+		MaxAttempts: maxAttempts,
+	}
+	if err := db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Decode unmarshals the job payload into v
+func Decode(job *models.Job, v interface{}) error {
+	return json.Unmarshal(job.Payload, v)
+}
+
+// Start launches the workers of every registered type and seeds recurring jobs. Workers
+// stop when ctx is cancelled.
+func Start(ctx context.Context) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for name, t := range registry {
+		if t.opts.Every > 0 {
+			if err := ensureRecurring(name); err != nil {
+				log.Printf("Failed to schedule recurring job %s: %v", name, err)
+			}
+		}
+		for i := 0; i < t.opts.Concurrency; i++ {
+			go work(ctx, name, t)
+		}
+	}
+}
+
+// ensureRecurring queues a run of a recurring type unless one is already queued. The
+// lock keeps instances starting together from queueing one each.
+func ensureRecurring(name string) error {
+	_, err := database.TryLock("queue.recurring."+name, 30*time.Second, func() error {
+		var count int64
+		err := database.DB.Model(&models.Job{}).
+			Where("type = ? AND status IN ?", name, []string{models.JobPending, models.JobRunning}).
+			Count(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+		_, err = Enqueue(database.DB, name, struct{}{}, time.Time{})
+		return err
+	})
+	return err
+}
+
+func work(ctx context.Context, name string, t jobType) {
+	for {
+		job, err := lease(name, t.opts.Lease)
+		if err != nil {
+			log.Printf("Job queue: failed to lease %s: %v", name, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		run(ctx, job, t)
+	}
+}
+
+// due matches jobs that may be leased: pending and due, or running with an expired lease
+const due = "((status = ? AND run_at <= ?) OR (status = ? AND leased_until < ?))"
+
+// lease claims the next due job of a type, or returns nil when there is none. The
+// conditional update makes sure only one worker wins a job.
+func lease(name string, d time.Duration) (*models.Job, error) {
+	now := time.Now()
+	var job models.Job
+	err := database.DB.Where("type = ? AND "+due, name, models.JobPending, now, models.JobRunning, now).
+		Order("run_at asc").Order("id asc").
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	until := now.Add(d)
+	result := database.DB.Model(&models.Job{}).
+		Where("id = ? AND "+due, job.ID, models.JobPending, now, models.JobRunning, now).
+		Updates(map[string]interface{}{
+			"status":       models.JobRunning,
+			"leased_until": until,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"updated_at":   now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	job.Status = models.JobRunning
+	job.LeasedUntil = &until
+	job.Attempts++
+	return &job, nil
+}
+
+func run(ctx context.Context, job *models.Job, t jobType) {
+	jobCtx, cancel := context.WithDeadline(ctx, *job.LeasedUntil)
+	err := safeCall(jobCtx, t.handler, job)
+	cancel()
+
+	now := time.Now()
+	updates := map[string]interface{}{"leased_until": nil, "updated_at": now}
+	switch {
+	case err == nil:
+		updates["status"] = models.JobSucceeded
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %d (%s) failed permanently: %v", job.ID, job.Type, err)
+		updates["status"] = models.JobDead
+		updates["finished_at"] = now
+		updates["last_error"] = err.Error()
+	default:
+		log.Printf("Job %d (%s) failed, retrying: %v", job.ID, job.Type, err)
+		updates["status"] = models.JobPending
+		updates["run_at"] = now.Add(Backoff(job.Attempts))
+		updates["last_error"] = err.Error()
+	}
+
+	// attempts identifies this lease; if the job was re-leased or cancelled meanwhile the
+	// result is dropped
+	result := database.DB.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, models.JobRunning, job.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("Job %d: failed to record result: %v", job.ID, result.Error)
+		return
+	}
+
+	if t.opts.Every > 0 && updates["status"] != models.JobPending && result.RowsAffected > 0 {
+		if _, err := Enqueue(database.DB, job.Type, struct{}{}, now.Add(t.opts.Every)); err != nil {
+			log.Printf("Failed to schedule next %s run: %v", job.Type, err)
+		}
+	}
+}
+
+// safeCall turns a handler panic into a job failure instead of killing the worker
+func safeCall(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// Backoff is the delay before retrying a job that failed its attempt-th attempt:
+// 10s, 20s, 40s, ... capped at one hour
+func Backoff(attempt int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempt && d < backoffMax; i++ {
+		d *= 2
+	}
+	if d > backoffMax {
+		d = backoffMax
+	}
+	return d
+}
@@ -0,0 +1,309 @@
+package renderer
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"content-flow/internal/pkgs/blocks"
+)
+
+type textData struct {
+	Text    string `json:"text"`
+	Caption string `json:"caption"`
+}
+
+type headingData struct {
+	Text  string `json:"text"`
+	Level int    `json:"level"`
+}
+
+type imageData struct {
+	URL     string `json:"url"`
+	Alt     string `json:"alt"`
+	Caption string `json:"caption"`
+}
+
+type embedData struct {
+	URL     string `json:"url"`
+	Service string `json:"service"`
+	Caption string `json:"caption"`
+}
+
+type listData struct {
+	Style string   `json:"style"`
+	Items []string `json:"items"`
+}
+
+type codeData struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+}
+
+func init() {
+	// Paragraph
+	Register(HTML, "paragraph", func(b blocks.Block) (string, error) {
+		var d textData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		return "<p>" + html.EscapeString(d.Text) + "</p>", nil
+	})
+	Register(Markdown, "paragraph", func(b blocks.Block) (string, error) {
+		var d textData
+		err := decode(b, &d)
+		return d.Text, err
+	})
+	Register(Text, "paragraph", func(b blocks.Block) (string, error) {
+		var d textData
+		err := decode(b, &d)
+		return d.Text, err
+	})
+
+	// Heading
+	Register(HTML, "heading", func(b blocks.Block) (string, error) {
+		var d headingData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		tag := "h" + strconv.Itoa(clampLevel(d.Level))
+		return "<" + tag + ">" + html.EscapeString(d.Text) + "</" + tag + ">", nil
+	})
+	Register(Markdown, "heading", func(b blocks.Block) (string, error) {
+		var d headingData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		return strings.Repeat("#", clampLevel(d.Level)) + " " + d.Text, nil
+	})
+	Register(Text, "heading", func(b blocks.Block) (string, error) {
+		var d headingData
+		err := decode(b, &d)
+		return d.Text, err
+	})
+
+	// Image
+	Register(HTML, "image", func(b blocks.Block) (string, error) {
+		var d imageData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		out := fmt.Sprintf(`<figure><img src="%s" alt="%s">`, html.EscapeString(safeURL(d.URL)), html.EscapeString(d.Alt))
+		if d.Caption != "" {
+			out += "<figcaption>" + html.EscapeString(d.Caption) + "</figcaption>"
+		}
+		return out + "</figure>", nil
+	})
+	Register(Markdown, "image", func(b blocks.Block) (string, error) {
+		var d imageData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		out := "![" + markdownText.Replace(d.Alt) + "](" + markdownURL.Replace(safeURL(d.URL)) + ")"
+		if d.Caption != "" {
+			out += "\n*" + d.Caption + "*"
+		}
+		return out, nil
+	})
+	Register(Text, "image", func(b blocks.Block) (string, error) {
+		var d imageData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		if d.Caption != "" {
+			return d.Caption, nil
+		}
+		return d.Alt, nil
+	})
+
+	// Quote
+	Register(HTML, "quote", func(b blocks.Block) (string, error) {
+		var d textData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		out := "<blockquote><p>" + html.EscapeString(d.Text) + "</p>"
+		if d.Caption != "" {
+			out += "<cite>" + html.EscapeString(d.Caption) + "</cite>"
+		}
+		return out + "</blockquote>", nil
+	})
+	Register(Markdown, "quote", func(b blocks.Block) (string, error) {
+		var d textData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		out := "> " + strings.ReplaceAll(d.Text, "\n", "\n> ")
+		if d.Caption != "" {
+			out += "\n>\n> — " + d.Caption
+		}
+		return out, nil
+	})
+	Register(Text, "quote", func(b blocks.Block) (string, error) {
+		var d textData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		out := "\"" + d.Text + "\""
+		if d.Caption != "" {
+			out += " — " + d.Caption
+		}
+		return out, nil
+	})
+
+	// Embed (rendered as a link; consumers can upgrade known services to players)
+	Register(HTML, "embed", func(b blocks.Block) (string, error) {
+		var d embedData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		label := d.Caption
+		if label == "" {
+			label = d.URL
+		}
+		link := html.EscapeString(label)
+		if href := safeURL(d.URL); href != "" {
+			link = `<a href="` + html.EscapeString(href) + `">` + link + "</a>"
+		}
+		return fmt.Sprintf(`<figure class="embed" data-service="%s">%s</figure>`, html.EscapeString(d.Service), link), nil
+	})
+	Register(Markdown, "embed", func(b blocks.Block) (string, error) {
+		var d embedData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		label := d.Caption
+		if label == "" {
+			label = d.URL
+		}
+		href := safeURL(d.URL)
+		if href == "" {
+			return markdownText.Replace(label), nil
+		}
+		return "[" + markdownText.Replace(label) + "](" + markdownURL.Replace(href) + ")", nil
+	})
+	Register(Text, "embed", func(b blocks.Block) (string, error) {
+		var d embedData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		if d.Caption != "" {
+			return d.Caption + " (" + d.URL + ")", nil
+		}
+		return d.URL, nil
+	})
+
+	// List
+	Register(HTML, "list", func(b blocks.Block) (string, error) {
+		var d listData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		tag := "ul"
+		if d.Style == "ordered" {
+			tag = "ol"
+		}
+		var sb strings.Builder
+		sb.WriteString("<" + tag + ">")
+		for _, item := range d.Items {
+			sb.WriteString("<li>" + html.EscapeString(item) + "</li>")
+		}
+		sb.WriteString("</" + tag + ">")
+		return sb.String(), nil
+	})
+	listLines := func(b blocks.Block) (string, error) {
+		var d listData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		lines := make([]string, len(d.Items))
+		for i, item := range d.Items {
+			if d.Style == "ordered" {
+				lines[i] = strconv.Itoa(i+1) + ". " + item
+			} else {
+				lines[i] = "- " + item
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	Register(Markdown, "list", listLines)
+	Register(Text, "list", listLines)
+
+	// Code
+	Register(HTML, "code", func(b blocks.Block) (string, error) {
+		var d codeData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		class := ""
+		if d.Language != "" {
+			class = ` class="language-` + html.EscapeString(d.Language) + `"`
+		}
+		return "<pre><code" + class + ">" + html.EscapeString(d.Code) + "</code></pre>", nil
+	})
+	Register(Markdown, "code", func(b blocks.Block) (string, error) {
+		var d codeData
+		if err := decode(b, &d); err != nil {
+			return "", err
+		}
+		return "```" + d.Language + "\n" + d.Code + "\n```", nil
+	})
+	Register(Text, "code", func(b blocks.Block) (string, error) {
+		var d codeData
+		err := decode(b, &d)
+		return d.Code, err
+	})
+}
+
+// fallbackFor renders blocks without a registered renderer (usually custom block
+// types) from their "text" field, so they degrade to readable output.
+func fallbackFor(format Format) RenderFunc {
+	return func(b blocks.Block) (string, error) {
+		var d textData
+		// Custom data may not be an object with a text field; that is not an error here
+		_ = decode(b, &d)
+		if d.Text == "" {
+			return "", nil
+		}
+		if format == HTML {
+			return fmt.Sprintf(`<div data-block-type="%s">%s</div>`, html.EscapeString(b.Type), html.EscapeString(d.Text)), nil
+		}
+		return d.Text, nil
+	}
+}
+
+func clampLevel(level int) int {
+	if level < 1 {
+		return 1
+	}
+	if level > 6 {
+		return 6
+	}
+	return level
+}
+
+// markdownText escapes the characters that would end link text or start a link of its own
+var markdownText = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`)
+
+// markdownURL percent-encodes the characters that would end a link destination early
+var markdownURL = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// safeURL returns rawURL when it is an http(s) or relative URL and "" otherwise, so
+// stored data cannot put javascript: or data: URLs in an href or src
+func safeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "":
+		return rawURL
+	case "http", "https":
+		if u.Host != "" {
+			return rawURL
+		}
+	}
+	return ""
+}
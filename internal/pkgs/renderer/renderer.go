@@ -0,0 +1,108 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"content-flow/internal/pkgs/blocks"
+)
+
+// Format is an output format supported by the renderer
+type Format string
+
+const (
+	HTML     Format = "html"
+	Markdown Format = "markdown"
+	Text     Format = "text"
+)
+
+// RenderFunc renders the data of a single block in one format
+type RenderFunc func(block blocks.Block) (string, error)
+
+var (
+	mu        sync.RWMutex
+	renderers = map[Format]map[string]RenderFunc{
+		HTML:     {},
+		Markdown: {},
+		Text:     {},
+	}
+)
+
+// ParseFormat validates a format name coming from a query string. Empty means HTML.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return HTML, nil
+	case HTML, Markdown, Text:
+		return f, nil
+	case "md":
+		return Markdown, nil
+	case "plain", "txt":
+		return Text, nil
+	}
+	return "", errors.New("unsupported format " + s + " (use html, markdown or text)")
+}
+
+// Register installs (or replaces) the renderer for a block type in the given format.
+// Custom block types without a renderer fall back to their "text" field.
+func Register(format Format, blockType string, fn RenderFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	if renderers[format] == nil {
+		renderers[format] = map[string]RenderFunc{}
+	}
+	renderers[format][blockType] = fn
+}
+
+// Render renders a Blocks payload as a single document
+func Render(raw []byte, format Format) (string, error) {
+	list, err := blocks.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return RenderBlocks(list, format)
+}
+
+// RenderBlocks renders already-parsed blocks as a single document
+func RenderBlocks(list []blocks.Block, format Format) (string, error) {
+	mu.RLock()
+	byType := renderers[format]
+	mu.RUnlock()
+	if byType == nil {
+		return "", errors.New("unsupported format " + string(format))
+	}
+
+	parts := make([]string, 0, len(list))
+	for _, b := range list {
+		mu.RLock()
+		fn, ok := byType[b.Type]
+		mu.RUnlock()
+		if !ok {
+			fn = fallbackFor(format)
+		}
+
+		out, err := fn(b)
+		if err != nil {
+			return "", errors.New("block " + b.Type + ": " + err.Error())
+		}
+		if out != "" {
+			parts = append(parts, out)
+		}
+	}
+
+	sep := "\n\n"
+	if format == HTML {
+		sep = "\n"
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// decode unmarshals block data into v, treating missing data as an empty object
+func decode(b blocks.Block, v interface{}) error {
+	if len(b.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b.Data, v)
+}
@@ -0,0 +1,83 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength caps generated slugs; longer input is cut at a word boundary
+const MaxLength = 96
+
+// transliterations covers letters that do not decompose into an ASCII base letter
+// plus combining marks (ı, ß, ø ...). Everything else goes through NFD.
+var transliterations = map[rune]string{
+	'ı': "i", 'İ': "i", 'ş': "s", 'Ş': "s", 'ğ': "g", 'Ğ': "g",
+	'ç': "c", 'Ç': "c", 'ö': "o", 'Ö': "o", 'ü': "u", 'Ü': "u",
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe",
+	'ø': "o", 'Ø': "o", 'đ': "d", 'Đ': "d", 'ł': "l", 'Ł': "l",
+	'þ': "th", 'Þ': "th", 'ð': "d", 'Ð': "d",
+	'&': " and ",
+}
+
+// Make turns s into a lowercase ASCII slug: "Güzel Şehir İstanbul" -> "guzel-sehir-istanbul".
+// Letters outside the Latin script (e.g. Cyrillic, CJK) are kept as-is, lowercased.
+// Returns "" when s has nothing sluggable.
+func Make(s string) string {
+	var mapped strings.Builder
+	for _, r := range s {
+		if t, ok := transliterations[r]; ok {
+			mapped.WriteString(t)
+		} else {
+			mapped.WriteRune(r)
+		}
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(mapped.String()) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining mark left over from NFD (é -> e + ´)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			dash = true
+		}
+	}
+
+	out := norm.NFC.String(b.String())
+	if len(out) > MaxLength {
+		out = out[:MaxLength]
+		if i := strings.LastIndexByte(out, '-'); i > 0 {
+			out = out[:i]
+		}
+		out = strings.ToValidUTF8(out, "")
+	}
+	return out
+}
+
+// Unique returns base, or base-2, base-3 ... for the first candidate taken reports as
+// free. fallback is used when base is empty.
+func Unique(base, fallback string, taken func(candidate string) (bool, error)) (string, error) {
+	if base == "" {
+		base = fallback
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		used, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return candidate, nil
+		}
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+}
@@ -45,3 +45,24 @@ func msgForTag(tag string, param string) string {
 		return "Invalid value"
 	}
 }
+
+// ValidationError carries field-level errors out of the service layer so handlers
+// can render them exactly like ValidateStruct failures.
+type ValidationError struct {
+	Errors []*ErrorResponse
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 0 {
+		return "Validation failed"
+	}
+	return "Validation failed: " + e.Errors[0].Field + ": " + e.Errors[0].Message
+}
+
+// NewValidationError returns nil when there are no errors, so callers can return it directly.
+func NewValidationError(errs []*ErrorResponse) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
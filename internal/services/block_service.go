@@ -0,0 +1,116 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/blocks"
+	"content-flow/internal/pkgs/validator"
+	"encoding/json"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+var blockTypeName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// GetBlockDefinitions returns the built-in block types followed by the custom ones
+func GetBlockDefinitions(db *gorm.DB) ([]blocks.Definition, error) {
+	defs := blocks.BuiltIns()
+
+	var custom []models.BlockType
+	if err := db.Order("type asc").Find(&custom).Error; err != nil {
+		return nil, err
+	}
+	for _, bt := range custom {
+		defs = append(defs, blocks.Definition{
+			Type:        bt.Type,
+			Label:       bt.Label,
+			Description: bt.Description,
+			Schema:      json.RawMessage(bt.Schema),
+		})
+	}
+	return defs, nil
+}
+
+// ValidateBlocks checks a Blocks payload against the registered block types
+func ValidateBlocks(db *gorm.DB, raw []byte) error {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	defs, err := GetBlockDefinitions(db)
+	if err != nil {
+		return err
+	}
+	byType := make(map[string]blocks.Definition, len(defs))
+	for _, d := range defs {
+		byType[d.Type] = d
+	}
+	return validator.NewValidationError(blocks.Validate(raw, byType))
+}
+
+func CreateBlockType(blockType, label, description string, schema json.RawMessage) (*models.BlockType, error) {
+	if err := checkBlockTypeName(blockType); err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	bt := &models.BlockType{
+		Type:        blockType,
+		Label:       label,
+		Description: description,
+		Schema:      normalized,
+	}
+	err = database.DB.Create(bt).Error
+	return bt, err
+}
+
+func GetBlockTypeByID(id uint) (*models.BlockType, error) {
+	var bt models.BlockType
+	if err := database.DB.First(&bt, id).Error; err != nil {
+		return nil, err
+	}
+	return &bt, nil
+}
+
+func UpdateBlockType(id uint, blockType, label, description string, schema json.RawMessage) (*models.BlockType, error) {
+	bt, err := GetBlockTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBlockTypeName(blockType); err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	bt.Type = blockType
+	bt.Label = label
+	bt.Description = description
+	bt.Schema = normalized
+
+	err = database.DB.Save(bt).Error
+	return bt, err
+}
+
+func DeleteBlockType(id uint) error {
+	return database.DB.Delete(&models.BlockType{}, id).Error
+}
+
+func checkBlockTypeName(blockType string) error {
+	var msg string
+	switch {
+	case !blockTypeName.MatchString(blockType):
+		msg = "Must start with a lowercase letter and contain only a-z, 0-9, '-' or '_'"
+	case blocks.IsBuiltIn(blockType):
+		msg = "Reserved by a built-in block type"
+	default:
+		return nil
+	}
+	return validator.NewValidationError([]*validator.ErrorResponse{{Field: "type", Message: msg}})
+}
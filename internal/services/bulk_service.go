@@ -0,0 +1,429 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/presence"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// maxBulkItems caps how many items one bulk request may change
+const maxBulkItems = 200
+
+// ErrBulkRolledBack is reported for the items of an atomic bulk request that were undone,
+// or never tried, because another item failed
+var ErrBulkRolledBack = errors.New("rolled back because another item failed")
+
+// BulkItemResult is the outcome of a bulk action on one content item
+type BulkItemResult struct {
+	ID         uint
+	Err        error  // nil on success
+	VersionTag string // Version tag after the change; empty once deleted
+}
+
+// BulkResult reports a bulk action item by item
+type BulkResult struct {
+	Results   []BulkItemResult
+	Succeeded int
+	Failed    int
+}
+
+// BulkContent applies req.Action to the items picked by req.IDs or req.Filter, as the
+// viewer sees them, on behalf of actorID. Each change is made the way the single-item
+// endpoints make it: status changes take workflow transitions and, like edits, are
+// saved as a new version, or go to the working draft of content that has one. Webhooks
+// are queued with every item, so they fire for committed items only. In atomic mode one
+// failure rolls back all items.
+func BulkContent(req *models.ContentBulkRequest, viewer Viewer, actorID uint) (*BulkResult, error) {
+	if err := checkBulkRequest(req); err != nil {
+		return nil, err
+	}
+	ids, err := bulkTargets(req, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{Results: make([]BulkItemResult, len(ids))}
+	var committed []func()
+
+	if req.Atomic {
+		failed := -1
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			for i, id := range ids {
+				tag, notify, err := applyBulkAction(tx, id, req, viewer, actorID)
+				result.Results[i] = BulkItemResult{ID: id, Err: err, VersionTag: tag}
+				if err != nil {
+					failed = i
+					return err
+				}
+				committed = append(committed, notify...)
+			}
+			return nil
+		})
+		if err != nil {
+			for i, id := range ids {
+				if i != failed {
+					result.Results[i] = BulkItemResult{ID: id, Err: ErrBulkRolledBack}
+				}
+			}
+			if failed < 0 {
+				return nil, err
+			}
+			committed = nil
+		}
+	} else {
+		for i, id := range ids {
+			var tag string
+			var notify []func()
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				var err error
+				tag, notify, err = applyBulkAction(tx, id, req, viewer, actorID)
+				return err
+			})
+			result.Results[i] = BulkItemResult{ID: id, Err: err, VersionTag: tag}
+			if err == nil {
+				committed = append(committed, notify...)
+			}
+		}
+	}
+
+	for _, r := range result.Results {
+		if r.Err == nil {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	for _, notify := range committed {
+		notify()
+	}
+	return result, nil
+}
+
+// checkBulkRequest validates the parameters the action needs
+func checkBulkRequest(req *models.ContentBulkRequest) error {
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		return invalidFilter("ids", "Pass either ids or filter")
+	}
+
+	switch req.Action {
+	case models.BulkSchedule:
+		if req.PublishedAt == nil {
+			return invalidFilter("published_at", "published_at is required to schedule content")
+		}
+	case models.BulkAddTags, models.BulkRemoveTags:
+		if len(req.Tags) == 0 {
+			return invalidFilter("tags", "tags is required for "+req.Action)
+		}
+	case models.BulkSetCategories:
+		ids := uniqueIDs(req.CategoryIDs)
+		var count int64
+		if err := database.DB.Model(&models.Category{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(ids)) {
+			return invalidFilter("category_ids", "Unknown category")
+		}
+	case models.BulkChangeAuthor:
+		if req.AuthorID == 0 {
+			return invalidFilter("author_id", "author_id is required for "+req.Action)
+		}
+		if _, err := GetUserByID(req.AuthorID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return invalidFilter("author_id", "Unknown user")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// bulkTargets resolves the IDs of the items to change. Items picked by ID that the viewer
+// cannot see are reported as not found when the action is applied.
+func bulkTargets(req *models.ContentBulkRequest, viewer Viewer) ([]uint, error) {
+	if len(req.IDs) > 0 {
+		return uniqueIDs(req.IDs), nil
+	}
+
+	f := req.Filter
+	filter := ContentFilter{
+		Viewer:   viewer,
+		Search:   f.Search,
+		Type:     f.Type,
+		Status:   f.Status,
+		Language: f.Language,
+		Tags:     f.Tags,
+	}
+	for _, a := range f.Attributes {
+		op := a.Op
+		if op == "" {
+			op = "eq"
+		}
+		filter.Attributes = append(filter.Attributes, AttributeFilter{Path: a.Path, Op: op, Value: a.Value})
+	}
+	query, err := filteredContent(filter)
+	if err != nil {
+		return nil, err
+	}
+	if req.Action == models.BulkRestore {
+		query = query.Unscoped().Where("contents.deleted_at IS NOT NULL")
+	}
+
+	var ids []uint
+	if err := query.Order("contents.id").Limit(maxBulkItems+1).Pluck("contents.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > maxBulkItems {
+		return nil, invalidFilter("filter", fmt.Sprintf("Matches more than %d items; narrow it down", maxBulkItems))
+	}
+	return ids, nil
+}
+
+// applyBulkAction changes one item inside tx. It returns the version tag after the change
+// and the notifications to send once tx has committed.
+func applyBulkAction(tx *gorm.DB, id uint, req *models.ContentBulkRequest, viewer Viewer, actorID uint) (string, []func(), error) {
+	var content models.Content
+	query := tx.Scopes(viewer.Scope)
+	if req.Action == models.BulkRestore {
+		query = query.Unscoped().Where("contents.deleted_at IS NOT NULL")
+	}
+	if err := query.First(&content, id).Error; err != nil {
+		return "", nil, err
+	}
+	if err := checkVersion(tx, &content, string(req.ExpectedVersions[id])); err != nil {
+		return "", nil, err
+	}
+
+	var notify []func()
+	switch req.Action {
+	case models.BulkPublish:
+		if content.Status != models.StatusPublished {
+			return bulkTransition(tx, &content, models.StatusPublished, req, actorID)
+		}
+		if err := publishDraft(tx, &content, actorID); err != nil {
+			if errors.Is(err, ErrNoDraft) {
+				return "", nil, invalidFilter("status", "Content is already "+models.StatusPublished)
+			}
+			return "", nil, err
+		}
+		saved, err := contentSaved(tx, content, actorID)
+		if err != nil {
+			return "", nil, err
+		}
+		notify = append(notify, saved)
+
+	case models.BulkUnpublish:
+		if content.Status != models.StatusPublished {
+			return "", nil, invalidFilter("status", "Content is not "+models.StatusPublished)
+		}
+		return bulkTransition(tx, &content, models.StatusDraft, req, actorID)
+
+	case models.BulkSchedule:
+		return bulkTransition(tx, &content, models.StatusScheduled, req, actorID)
+
+	case models.BulkDelete:
+		if err := tx.Delete(&content).Error; err != nil {
+			return "", nil, err
+		}
+		if err := unindexContent(tx, content.ID); err != nil {
+			return "", nil, err
+		}
+		if err := TriggerWebhooks(tx, "content.delete", content); err != nil {
+			return "", nil, err
+		}
+		return "", []func(){func() { presence.Notify(content.ID, DeletedEvent{Type: "deleted"}) }}, nil
+
+	case models.BulkRestore:
+		if err := checkContentSlug(tx, content.Slug, content.Language, content.ID); err != nil {
+			return "", nil, err
+		}
+		if err := tx.Unscoped().Model(&content).Update("deleted_at", nil).Error; err != nil {
+			return "", nil, err
+		}
+		content.DeletedAt = gorm.DeletedAt{}
+		if err := indexContent(tx, &content); err != nil {
+			return "", nil, err
+		}
+		if err := TriggerWebhooks(tx, "content.restore", content); err != nil {
+			return "", nil, err
+		}
+
+	case models.BulkAddTags, models.BulkRemoveTags, models.BulkSetCategories:
+		drafted, err := bulkTaxonomies(tx, &content, req, actorID)
+		if err != nil {
+			return "", nil, err
+		}
+		if drafted {
+			// Draft saves fire no webhooks, but editors learn the new version tag
+			notify = append(notify, func() { notifySaved(content.ID, actorID) })
+		} else {
+			saved, err := contentSaved(tx, content, actorID)
+			if err != nil {
+				return "", nil, err
+			}
+			notify = append(notify, saved)
+		}
+
+	case models.BulkChangeAuthor:
+		if err := newVersion(tx, &content, req.ChangeMessage, actorID); err != nil {
+			return "", nil, err
+		}
+		content.AuthorID = req.AuthorID
+		if err := tx.Save(&content).Error; err != nil {
+			return "", nil, err
+		}
+		saved, err := contentSaved(tx, content, actorID)
+		if err != nil {
+			return "", nil, err
+		}
+		notify = append(notify, saved)
+	}
+
+	tag, err := versionTag(tx, &content)
+	return tag, notify, err
+}
+
+// bulkTransition moves content to status to and saves the change as a new version. The
+// transition is taken on the current version, like TransitionContent does, since that is
+// the version reviewers approved.
+func bulkTransition(tx *gorm.DB, content *models.Content, to string, req *models.ContentBulkRequest, actorID uint) (string, []func(), error) {
+	if err := snapshotContent(tx, content); err != nil {
+		return "", nil, err
+	}
+	transition, entry, err := takeTransition(tx, content, to, req.ChangeMessage, req.PublishedAt, actorID)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := bumpVersion(tx, content); err != nil {
+		return "", nil, err
+	}
+	content.UpdatedByID = actorID
+	content.ChangeMessage = req.ChangeMessage
+	if err := tx.Save(content).Error; err != nil {
+		return "", nil, err
+	}
+
+	saved, err := contentSaved(tx, *content, actorID)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := notifyTransition(tx, *content, transition, entry); err != nil {
+		return "", nil, err
+	}
+	tag, err := versionTag(tx, content)
+	return tag, []func(){saved}, err
+}
+
+// bulkTaxonomies adds or removes tags or replaces the categories of content as a new
+// version. Content whose edits go to its working draft gets the change in the draft.
+func bulkTaxonomies(tx *gorm.DB, content *models.Content, req *models.ContentBulkRequest, actorID uint) (drafted bool, err error) {
+	if drafted, err = editsDraft(tx, content); err != nil {
+		return false, err
+	}
+
+	var changes models.ContentVersion
+	if drafted {
+		draft, err := loadDraft(tx, content)
+		if err != nil {
+			return true, err
+		}
+		changes.CategoryIDs, changes.Tags = draft.CategoryIDs, draft.Tags
+		if err := changeTaxonomies(&changes, req); err != nil {
+			return true, err
+		}
+		draft.CategoryIDs, draft.Tags = changes.CategoryIDs, changes.Tags
+		draft.UpdatedByID = actorID
+		draft.ChangeMessage = req.ChangeMessage
+		if draft.ID == 0 {
+			if draft.Version, err = firstDraftVersion(tx, content); err != nil {
+				return true, err
+			}
+		} else if err := bumpDraftVersion(tx, draft); err != nil {
+			return true, err
+		}
+		return true, tx.Save(draft).Error
+	}
+
+	if changes.CategoryIDs, changes.Tags, err = contentTaxonomies(tx, content.ID); err != nil {
+		return false, err
+	}
+	if err := changeTaxonomies(&changes, req); err != nil {
+		return false, err
+	}
+	if err := newVersion(tx, content, req.ChangeMessage, actorID); err != nil {
+		return false, err
+	}
+	if err := tx.Save(content).Error; err != nil {
+		return false, err
+	}
+	return false, restoreTaxonomies(tx, content, &changes)
+}
+
+// changeTaxonomies applies the tag or category change of req to the JSON lists in v
+func changeTaxonomies(v *models.ContentVersion, req *models.ContentBulkRequest) error {
+	if req.Action == models.BulkSetCategories {
+		categoryIDs, err := json.Marshal(uniqueIDs(req.CategoryIDs))
+		v.CategoryIDs = categoryIDs
+		return err
+	}
+
+	var tags []string
+	if err := unmarshalSnapshot(v.Tags, &tags); err != nil {
+		return err
+	}
+	changed := []string{}
+	for _, tag := range tags {
+		if req.Action == models.BulkAddTags || !containsString(req.Tags, tag) {
+			changed = append(changed, tag)
+		}
+	}
+	if req.Action == models.BulkAddTags {
+		for _, tag := range req.Tags {
+			if !containsString(changed, tag) {
+				changed = append(changed, tag)
+			}
+		}
+	}
+	names, err := json.Marshal(changed)
+	v.Tags = names
+	return err
+}
+
+// newVersion snapshots content and moves it to its next version made by actorID; the
+// caller applies its change and saves
+func newVersion(tx *gorm.DB, content *models.Content, changeMessage string, actorID uint) error {
+	if err := snapshotContent(tx, content); err != nil {
+		return err
+	}
+	if err := bumpVersion(tx, content); err != nil {
+		return err
+	}
+	content.UpdatedByID = actorID
+	content.ChangeMessage = changeMessage
+	return nil
+}
+
+// contentSaved announces a change of content like UpdateContent does: the webhook is
+// queued in tx and the returned notification tells the editors once tx has committed
+func contentSaved(tx *gorm.DB, content models.Content, actorID uint) (func(), error) {
+	if err := TriggerWebhooks(tx, "content.update", content); err != nil {
+		return nil, err
+	}
+	return func() { notifySaved(content.ID, actorID) }, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	unique := []uint{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
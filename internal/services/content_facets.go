@@ -0,0 +1,76 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// columnFacets are facets over a single contents column
+var columnFacets = map[string]string{
+	"type":     "contents.type",
+	"status":   "contents.status",
+	"language": "contents.language",
+}
+
+// ParseFacets parses "type,tags,categories" into a list of facet names
+func ParseFacets(raw string) ([]string, error) {
+	var facets []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := columnFacets[name]; !ok && name != "tags" && name != "categories" {
+			return nil, invalidFilter("facets", "Unknown facet "+name+" (use type, status, language, tags, categories)")
+		}
+		if !containsString(facets, name) {
+			facets = append(facets, name)
+		}
+	}
+	return facets, nil
+}
+
+// GetContentFacets counts the content matching filter per facet value. Pagination,
+// sorting and fieldsets in filter are ignored.
+func GetContentFacets(filter ContentFilter, facets []string) (map[string][]models.FacetBucket, error) {
+	result := make(map[string][]models.FacetBucket, len(facets))
+	for _, name := range facets {
+		matching, err := filteredContent(filter)
+		if err != nil {
+			return nil, err
+		}
+		ids := matching.Select("contents.id")
+
+		var query *gorm.DB
+		switch name {
+		case "tags":
+			query = database.DB.Table("content_tags").
+				Select("tags.name AS value, COUNT(DISTINCT content_tags.content_id) AS count").
+				Joins("JOIN tags ON tags.id = content_tags.tag_id AND tags.deleted_at IS NULL").
+				Where("content_tags.content_id IN (?)", ids).
+				Group("tags.name")
+		case "categories":
+			query = database.DB.Table("content_categories").
+				Select("categories.slug AS value, categories.name AS label, COUNT(DISTINCT content_categories.content_id) AS count").
+				Joins("JOIN categories ON categories.id = content_categories.category_id AND categories.deleted_at IS NULL").
+				Where("content_categories.content_id IN (?)", ids).
+				Group("categories.slug, categories.name")
+		default:
+			column := columnFacets[name]
+			query = database.DB.Table("contents").
+				Select(column+" AS value, COUNT(*) AS count").
+				Where("contents.id IN (?)", ids).
+				Group(column)
+		}
+
+		buckets := []models.FacetBucket{}
+		if err := query.Order("count desc").Order("value asc").Scan(&buckets).Error; err != nil {
+			return nil, err
+		}
+		result[name] = buckets
+	}
+	return result, nil
+}
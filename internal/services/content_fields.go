@@ -0,0 +1,145 @@
+package services
+
+import (
+	"content-flow/internal/models"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// contentColumns maps selectable JSON field names to their contents column
+var contentColumns = map[string]string{
+	"id": "id", "title": "title", "slug": "slug", "body": "body", "type": "type",
+	"attributes": "attributes", "status": "status", "language": "language", "group_id": "group_id",
+	"version": "version", "author_id": "author_id", "published_at": "published_at", "unpublish_at": "unpublish_at",
+	"blocks": "blocks", "created_at": "created_at", "updated_at": "updated_at",
+	"updated_by_id": "updated_by_id", "change_message": "change_message",
+}
+
+// contentRelations are fields loaded outside the contents table, with the columns they
+// need to be computed
+var contentRelations = map[string][]string{
+	"categories": nil,
+	"tags":       nil,
+	"references": {"type"},
+	"rendered":   {"body", "blocks"},
+	"snippet":    nil,
+	"has_draft":  nil,
+	"revision":   nil,
+}
+
+// ContentFields is a sparse fieldset (?fields=id,title,tags). A nil *ContentFields
+// means "all fields" so callers can pass it through unconditionally.
+type ContentFields struct {
+	names map[string]bool
+}
+
+// ParseContentFields parses a comma separated field list; an empty list yields nil
+func ParseContentFields(raw string) (*ContentFields, error) {
+	fields := &ContentFields{names: map[string]bool{}}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		_, isColumn := contentColumns[name]
+		_, isRelation := contentRelations[name]
+		if !isColumn && !isRelation {
+			return nil, invalidFilter("fields", "Unknown field "+name)
+		}
+		fields.names[name] = true
+	}
+	if len(fields.names) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// Has reports whether name is part of the output
+func (f *ContentFields) Has(name string) bool {
+	return f == nil || f.names[name]
+}
+
+// Add requests an extra field, e.g. "rendered" when ?render= is given
+func (f *ContentFields) Add(name string) {
+	if f != nil {
+		f.names[name] = true
+	}
+}
+
+// Scope selects only the needed contents columns and preloads only the requested
+// taxonomies. extra lists columns the caller needs internally (e.g. created_at for
+// cursors); they are fetched but not projected.
+func (f *ContentFields) Scope(extra ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.Has("categories") {
+			db = db.Preload("Categories")
+		}
+		if f.Has("tags") {
+			db = db.Preload("Tags")
+		}
+		if f == nil {
+			return db
+		}
+
+		needed := map[string]bool{"id": true}
+		for _, c := range extra {
+			needed[c] = true
+		}
+		for name := range f.names {
+			if column, ok := contentColumns[name]; ok {
+				needed[column] = true
+			}
+			for _, column := range contentRelations[name] {
+				needed[column] = true
+			}
+		}
+		columns := make([]string, 0, len(needed))
+		for column := range needed {
+			columns = append(columns, "contents."+column)
+		}
+		sort.Strings(columns)
+		return db.Select(columns)
+	}
+}
+
+// Project returns content with only the requested JSON keys, or content itself when
+// no fieldset was given
+func (f *ContentFields) Project(content *models.Content) (interface{}, error) {
+	if f == nil {
+		return content, nil
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	projected := make(map[string]json.RawMessage, len(f.names))
+	for name := range f.names {
+		if value, ok := all[name]; ok {
+			projected[name] = value
+		}
+	}
+	return projected, nil
+}
+
+// ProjectAll applies Project to every item of a list
+func (f *ContentFields) ProjectAll(contents []models.Content) (interface{}, error) {
+	if f == nil {
+		return contents, nil
+	}
+	projected := make([]interface{}, len(contents))
+	for i := range contents {
+		item, err := f.Project(&contents[i])
+		if err != nil {
+			return nil, err
+		}
+		projected[i] = item
+	}
+	return projected, nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/pkgs/validator"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AttributeFilter is one condition on a JSON path inside Content.Attributes,
+// e.g. filter[attributes.price][lt]=50 -> {Path: "price", Op: "lt", Value: "50"}
+type AttributeFilter struct {
+	Path  string
+	Op    string
+	Value string
+}
+
+// SortField orders content by a column or by an attribute path ("attributes.rating")
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+var attributeOps = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+var sortableColumns = map[string]bool{
+	"id": true, "title": true, "slug": true, "type": true, "status": true, "language": true,
+	"version": true, "published_at": true, "created_at": true, "updated_at": true,
+}
+
+var attributePathSegment = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+const maxAttributePathDepth = 5
+
+// ParseAttributeFilter parses a query key such as "filter[attributes.price][lt]".
+// ok is false for keys that are not attribute filters.
+func ParseAttributeFilter(key, value string) (filter AttributeFilter, ok bool, err error) {
+	if !strings.HasPrefix(key, "filter[") {
+		return filter, false, nil
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]"), "][")
+	if !strings.HasPrefix(parts[0], "attributes.") || len(parts) > 2 {
+		return filter, true, invalidFilter(key, "Only filter[attributes.<path>][<op>] is supported")
+	}
+
+	filter = AttributeFilter{Path: strings.TrimPrefix(parts[0], "attributes."), Op: "eq", Value: value}
+	if len(parts) == 2 {
+		filter.Op = parts[1]
+	}
+	if err := filter.validate(); err != nil {
+		return filter, true, invalidFilter(key, err.Error())
+	}
+	return filter, true, nil
+}
+
+func (f AttributeFilter) validate() error {
+	if err := validateAttributePath(f.Path); err != nil {
+		return err
+	}
+	switch f.Op {
+	case "eq", "ne", "lt", "lte", "gt", "gte", "like", "in", "exists":
+	default:
+		return fmt.Errorf("Unknown operator %s (use eq, ne, lt, lte, gt, gte, like, in, exists)", f.Op)
+	}
+	// Values that parse as numbers are compared as numbers, which NaN and Inf cannot be
+	values := []string{f.Value}
+	if f.Op == "in" {
+		values = strings.Split(f.Value, ",")
+	}
+	for _, v := range values {
+		if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && (math.IsNaN(n) || math.IsInf(n, 0)) {
+			return fmt.Errorf("Value %s is not a finite number", strings.TrimSpace(v))
+		}
+	}
+	return nil
+}
+
+// ParseSort parses "-attributes.rating,created_at"; a leading "-" means descending
+func ParseSort(sort string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if path, ok := strings.CutPrefix(field.Field, "attributes."); ok {
+			if err := validateAttributePath(path); err != nil {
+				return nil, invalidFilter("sort", err.Error())
+			}
+		} else if !sortableColumns[field.Field] {
+			return nil, invalidFilter("sort", "Cannot sort by "+field.Field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func validateAttributePath(path string) error {
+	segments := strings.Split(path, ".")
+	if len(segments) > maxAttributePathDepth {
+		return fmt.Errorf("Attribute path %s is nested deeper than %d levels", path, maxAttributePathDepth)
+	}
+	for _, s := range segments {
+		if !attributePathSegment.MatchString(s) {
+			return fmt.Errorf("Invalid attribute path %s", path)
+		}
+	}
+	return nil
+}
+
+func invalidFilter(field, message string) error {
+	return validator.NewValidationError([]*validator.ErrorResponse{{Field: field, Message: message}})
+}
+
+// likeEscaper makes the wildcards of a like filter match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// applyAttributeFilter adds one attribute condition. Paths are validated against
+// attributePathSegment before they are inlined into the JSON path expressions.
+func applyAttributeFilter(query *gorm.DB, f AttributeFilter) *gorm.DB {
+	segments := strings.Split(f.Path, ".")
+
+	switch f.Op {
+	case "exists":
+		cond := jsonValueExpr(segments) + " IS NOT NULL"
+		if f.Value == "false" || f.Value == "0" {
+			cond = jsonValueExpr(segments) + " IS NULL"
+		}
+		return query.Where(cond)
+	case "like":
+		op := "LIKE"
+		if database.IsPostgres() {
+			op = "ILIKE"
+		}
+		return query.Where(jsonTextExpr(segments)+" "+op+` ? ESCAPE '\'`, "%"+likeEscaper.Replace(f.Value)+"%")
+	case "in":
+		values := strings.Split(f.Value, ",")
+		if nums, ok := parseNumbers(values); ok {
+			return query.Where(jsonNumberExpr(segments)+" IN ?", nums)
+		}
+		return query.Where(jsonTextExpr(segments)+" IN ?", values)
+	}
+
+	op := attributeOps[f.Op]
+	expr, arg := jsonTextExpr(segments), interface{}(f.Value)
+	if n, err := strconv.ParseFloat(f.Value, 64); err == nil {
+		expr, arg = jsonNumberExpr(segments), n
+	} else if f.Value == "true" || f.Value == "false" {
+		expr, arg = jsonBoolExpr(segments), f.Value == "true"
+	}
+
+	if f.Op == "ne" {
+		// Items without the attribute are "not equal" as well
+		return query.Where("("+expr+" IS NULL OR "+expr+" "+op+" ?)", arg)
+	}
+	return query.Where(expr+" "+op+" ?", arg)
+}
+
+// applySort orders by the given fields, falling back to newest first
+func applySort(query *gorm.DB, fields []SortField) *gorm.DB {
+	if len(fields) == 0 {
+		return query.Order("contents.created_at desc")
+	}
+	for _, f := range fields {
+		dir := " asc"
+		if f.Desc {
+			dir = " desc"
+		}
+		if path, ok := strings.CutPrefix(f.Field, "attributes."); ok {
+			query = query.Order(jsonValueExpr(strings.Split(path, ".")) + dir + " NULLS LAST")
+		} else {
+			query = query.Order("contents." + f.Field + dir)
+		}
+	}
+	return query
+}
+
+// SetupAttributeQueries creates the SQL function attributesDoc uses on PostgreSQL.
+// Call after AutoMigrate.
+func SetupAttributeQueries() {
+	if !database.IsPostgres() {
+		return
+	}
+	err := database.DB.Exec(`CREATE OR REPLACE FUNCTION try_jsonb(value TEXT) RETURNS JSONB AS $$
+		BEGIN
+			RETURN NULLIF(value, '')::jsonb;
+		EXCEPTION WHEN invalid_text_representation THEN
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql IMMUTABLE`).Error
+	if err != nil {
+		log.Println("Failed to set up attribute queries:", err)
+	}
+}
+
+// attributesDoc is contents.attributes as a JSON document, or NULL when it is empty or
+// not valid JSON, so that JSON functions never fail on legacy rows.
+func attributesDoc() string {
+	if database.IsPostgres() {
+		return "try_jsonb(contents.attributes)"
+	}
+	return "CASE WHEN json_valid(contents.attributes) THEN contents.attributes END"
+}
+
+func sqlitePath(segments []string) string {
+	return "'$." + strings.Join(segments, ".") + "'"
+}
+
+func postgresPath(segments []string) string {
+	return "'{" + strings.Join(segments, ",") + "}'"
+}
+
+// jsonValueExpr selects the raw JSON value (typed comparison and sorting)
+func jsonValueExpr(segments []string) string {
+	if database.IsPostgres() {
+		return "(" + attributesDoc() + " #> " + postgresPath(segments) + ")"
+	}
+	return "json_extract(" + attributesDoc() + ", " + sqlitePath(segments) + ")"
+}
+
+// jsonTextExpr selects the value as text
+func jsonTextExpr(segments []string) string {
+	if database.IsPostgres() {
+		return "(" + attributesDoc() + " #>> " + postgresPath(segments) + ")"
+	}
+	return "CAST(" + jsonValueExpr(segments) + " AS TEXT)"
+}
+
+// jsonNumberExpr selects the value when it is a JSON number and NULL otherwise
+func jsonNumberExpr(segments []string) string {
+	if database.IsPostgres() {
+		return "(CASE WHEN jsonb_typeof(" + jsonValueExpr(segments) + ") = 'number' THEN " + jsonTextExpr(segments) + "::numeric END)"
+	}
+	return "(CASE WHEN json_type(" + attributesDoc() + ", " + sqlitePath(segments) + ") IN ('integer', 'real') THEN " + jsonValueExpr(segments) + " END)"
+}
+
+// jsonBoolExpr selects the value when it is a JSON boolean and NULL otherwise
+func jsonBoolExpr(segments []string) string {
+	if database.IsPostgres() {
+		return "(CASE WHEN jsonb_typeof(" + jsonValueExpr(segments) + ") = 'boolean' THEN " + jsonTextExpr(segments) + "::boolean END)"
+	}
+	return "(CASE WHEN json_type(" + attributesDoc() + ", " + sqlitePath(segments) + ") IN ('true', 'false') THEN " + jsonValueExpr(segments) + " END)"
+}
+
+func parseNumbers(values []string) ([]float64, bool) {
+	nums := make([]float64, 0, len(values))
+	for _, v := range values {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+	return nums, true
+}
@@ -3,9 +3,13 @@ package services
 import (
 	"content-flow/internal/database"
 	"content-flow/internal/models"
+	"content-flow/internal/pkgs/pagination"
+	"content-flow/internal/pkgs/presence"
 	"encoding/json"
 	"errors"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,7 +17,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func CreateContent(content *models.Content, categoryIDs []uint, tagNames []string, publishedAt *time.Time, blocks json.RawMessage, authorID uint) error {
+func CreateContent(content *models.Content, categoryIDs []uint, tagNames []string, publishedAt *time.Time, blocks json.RawMessage, references map[string][]uint, authorID uint) error {
 	if content.Language == "" {
 		content.Language = "en"
 	}
@@ -21,11 +25,24 @@ func CreateContent(content *models.Content, categoryIDs []uint, tagNames []strin
 		content.GroupID = uuid.New().String()
 	}
 	content.AuthorID = authorID
+	content.UpdatedByID = authorID
 	content.Version = 1
 
+	if err := ValidateAttributes(database.DB, content.Type, content.Attributes); err != nil {
+		return err
+	}
+	if err := ValidateBlocks(database.DB, blocks); err != nil {
+		return err
+	}
+
 	if publishedAt != nil {
 		content.PublishedAt = publishedAt
 	}
+	transition, err := initialTransition(content, authorID)
+	if err != nil {
+		return err
+	}
+	stampPublishedAt(content)
 
 	if len(blocks) > 0 {
 		content.Blocks = datatypes.JSON(blocks)
@@ -40,29 +57,44 @@ func CreateContent(content *models.Content, categoryIDs []uint, tagNames []strin
 		content.Categories = categories
 	}
 
-	if len(tagNames) > 0 {
-		var tags []models.Tag
-		for _, name := range tagNames {
-			var tag models.Tag
-			if err := database.DB.FirstOrCreate(&tag, models.Tag{Name: name, Slug: name}).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := assignContentSlug(tx, content); err != nil {
+			return err
+		}
+		if len(tagNames) > 0 {
+			tags, err := syncTags(tx, tagNames)
+			if err != nil {
 				return err
 			}
-			tags = append(tags, tag)
+			content.Tags = tags
+		}
+		if err := tx.Create(content).Error; err != nil {
+			return err
+		}
+		if err := setReferences(tx, content, references); err != nil {
+			return err
+		}
+		if err := indexContent(tx, content); err != nil {
+			return err
 		}
-		content.Tags = tags
-	}
-
-	if err := database.DB.Create(content).Error; err != nil {
-		return err
-	}
-
-	// Trigger Webhook
-	TriggerWebhooks("content.create", content)
 
-	return nil
+		// Trigger Webhook
+		if err := TriggerWebhooks(tx, "content.create", content); err != nil {
+			return err
+		}
+		if transition == nil {
+			return nil
+		}
+		entry, err := recordTransition(tx, content, transition, models.StatusDraft, authorID, "")
+		if err != nil {
+			return err
+		}
+		return notifyTransition(tx, *content, transition, entry)
+	})
+	return err
 }
 
-func AddTranslation(originalContentID uint, translation *models.Content) error {
+func AddTranslation(originalContentID uint, translation *models.Content, blocks json.RawMessage, references map[string][]uint, actorID uint) error {
 	var original models.Content
 	if err := database.DB.First(&original, originalContentID).Error; err != nil {
 		return errors.New("original content not found")
@@ -75,53 +107,88 @@ func AddTranslation(originalContentID uint, translation *models.Content) error {
 		return errors.New("translation for this language already exists")
 	}
 
+	if err := ValidateAttributes(database.DB, translation.Type, translation.Attributes); err != nil {
+		return err
+	}
+	if err := ValidateBlocks(database.DB, blocks); err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		translation.Blocks = datatypes.JSON(blocks)
+	}
+
+	transition, err := initialTransition(translation, actorID)
+	if err != nil {
+		return err
+	}
+	stampPublishedAt(translation)
+
 	translation.GroupID = original.GroupID
+	translation.UpdatedByID = actorID
 	translation.Version = 1
 	// ID will be auto-generated because it's a new row
-	return database.DB.Create(translation).Error
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := assignContentSlug(tx, translation); err != nil {
+			return err
+		}
+		if err := tx.Create(translation).Error; err != nil {
+			return err
+		}
+		if err := setReferences(tx, translation, references); err != nil {
+			return err
+		}
+		if err := indexContent(tx, translation); err != nil {
+			return err
+		}
+		if transition == nil {
+			return nil
+		}
+		entry, err := recordTransition(tx, translation, transition, models.StatusDraft, actorID, "")
+		if err != nil {
+			return err
+		}
+		return notifyTransition(tx, *translation, transition, entry)
+	})
+	return err
 }
 
 type ContentFilter struct {
-	Search   string
-	Type     string
-	Status   string
-	Language string
-	Tags     []string
-	Page     int
-	Limit    int
+	Search     string
+	Type       string
+	Status     string
+	Language   string
+	Tags       []string
+	Attributes []AttributeFilter
+	Sort       []SortField
+	Fields     *ContentFields // nil returns every field
+	Viewer     Viewer         // Zero value lists what anonymous visitors may see
+	Page       int
+	Limit      int
+	// Keyset switches to cursor pagination ordered by created_at, id; Cursor is nil on the first page
+	Keyset    bool
+	Cursor    *pagination.Cursor
+	SkipTotal bool
 }
 
-func GetAllContent(filter ContentFilter) ([]models.Content, int64, error) {
+func GetAllContent(filter ContentFilter) ([]models.Content, pagination.Page, error) {
 	var contents []models.Content
-	var total int64
+	var page pagination.Page
 
-	query := database.DB.Model(&models.Content{}).Preload("Categories").Preload("Tags")
-
-	if filter.Search != "" {
-		searchTerm := "%" + filter.Search + "%"
-		query = query.Where("title LIKE ? OR body LIKE ?", searchTerm, searchTerm)
-	}
-	if filter.Type != "" {
-		query = query.Where("type = ?", filter.Type)
-	}
-	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
-	}
-	if filter.Language != "" {
-		query = query.Where("language = ?", filter.Language)
+	query, err := filteredContent(filter)
+	if err != nil {
+		return nil, page, err
 	}
 
-	// Filtering by Tags (Join)
-	if len(filter.Tags) > 0 {
-		query = query.Joins("JOIN content_tags ON content_tags.content_id = contents.id").
-			Joins("JOIN tags ON tags.id = content_tags.tag_id").
-			Where("tags.name IN ?", filter.Tags).
-			Group("contents.id") // Remove duplicates if multiple tags match
+	if filter.Keyset && len(filter.Sort) > 0 {
+		return nil, page, invalidFilter("sort", "sort cannot be combined with cursor pagination")
 	}
 
 	// Count total before pagination
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if !filter.SkipTotal {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, page, err
+		}
+		page.HasTotal = true
 	}
 
 	// Pagination
@@ -131,24 +198,128 @@ func GetAllContent(filter ContentFilter) ([]models.Content, int64, error) {
 	if filter.Limit <= 0 {
 		filter.Limit = 10
 	}
+	// Callers other than the REST handlers (GraphQL) pass the requested limit unchecked
+	if filter.Limit > pagination.MaxLimit {
+		filter.Limit = pagination.MaxLimit
+	}
+
+	if filter.Keyset {
+		err := query.Scopes(filter.Fields.Scope("created_at"), pagination.Newest("contents", filter.Cursor, filter.Limit)).Find(&contents).Error
+		if err != nil {
+			return nil, page, err
+		}
+		contents, page.NextCursor = pagination.Trim(contents, filter.Limit, contentCursor)
+		return contents, page, searchSnippets(contents, filter)
+	}
+
+	// Search results are ordered by relevance unless a sort is given
+	query = query.Scopes(filter.Fields.Scope())
+	if filter.Search != "" && len(filter.Sort) == 0 {
+		query = query.Order("search.rank asc").Order("contents.id desc")
+	} else {
+		query = applySort(query, filter.Sort)
+	}
+
 	offset := (filter.Page - 1) * filter.Limit
+	if err = query.Limit(filter.Limit).Offset(offset).Find(&contents).Error; err != nil {
+		return nil, page, err
+	}
+	return contents, page, searchSnippets(contents, filter)
+}
+
+func searchSnippets(contents []models.Content, filter ContentFilter) error {
+	if filter.Search == "" || !filter.Fields.Has("snippet") {
+		return nil
+	}
+	return loadSnippets(contents, filter.Search)
+}
+
+// filteredContent builds the content query with every filter applied but no ordering,
+// pagination or column selection. Listings and facets share it.
+func filteredContent(filter ContentFilter) (*gorm.DB, error) {
+	query := database.DB.Model(&models.Content{}).Scopes(filter.Viewer.Scope)
+
+	if filter.Search != "" {
+		query = applySearch(query, filter.Search)
+	}
+	if filter.Type != "" {
+		query = query.Where("contents.type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("contents.status = ?", filter.Status)
+	}
+	if filter.Language != "" {
+		query = query.Where("contents.language = ?", filter.Language)
+	}
+	for _, f := range filter.Attributes {
+		if err := f.validate(); err != nil {
+			return nil, invalidFilter("filter[attributes."+f.Path+"]["+f.Op+"]", err.Error())
+		}
+		query = applyAttributeFilter(query, f)
+	}
+
+	// Filtering by Tags (subquery, so multiple matching tags don't duplicate rows)
+	if len(filter.Tags) > 0 {
+		query = query.Where("contents.id IN (?)", database.DB.Table("content_tags").
+			Select("content_tags.content_id").
+			Joins("JOIN tags ON tags.id = content_tags.tag_id").
+			Where("tags.name IN ?", filter.Tags))
+	}
+	return query, nil
+}
 
-	err := query.Limit(filter.Limit).Offset(offset).Order("created_at desc").Find(&contents).Error
-	return contents, total, err
+// stampPublishedAt records when content went live if no publish date was given, so
+// visibility checks against published_at hold
+func stampPublishedAt(content *models.Content) {
+	if content.Status == models.StatusPublished && content.PublishedAt == nil {
+		now := time.Now()
+		content.PublishedAt = &now
+	}
+}
+
+func contentCursor(c models.Content) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
 }
 
 func GetContentByID(id uint) (*models.Content, error) {
+	return GetVisibleContent(id, allContent, nil)
+}
+
+// GetVisibleContent loads content the viewer may see, with only the columns and
+// taxonomies in fields
+func GetVisibleContent(id uint, viewer Viewer, fields *ContentFields) (*models.Content, error) {
 	var content models.Content
-	err := database.DB.Preload("Categories").Preload("Tags").First(&content, id).Error
+	// The version is always loaded since it is served as the ETag
+	err := database.DB.Scopes(viewer.Scope, fields.Scope("version")).First(&content, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &content, nil
 }
 
-// UpdateContent handles versioning: saves old state to ContentVersion, then updates Content
-func UpdateContent(id uint, newTitle, newBody, newType, newAttributes, newStatus, newLang string, categoryIDs []uint, tagNames []string, publishedAt *time.Time, newBlocks json.RawMessage) (*models.Content, error) {
+// UpdateContent handles versioning: saves old state to ContentVersion, then updates Content.
+// A status change is a workflow transition taken by actorID; an empty status keeps the
+// current one. Published content keeps serving its live revision: the changes are saved
+// to its working draft instead and the draft revision is returned (see PublishDraft).
+// A non-empty expectedVersion must match the current VersionTag.
+func UpdateContent(id uint, newTitle, newSlug, newBody, newType, newAttributes, newStatus, newLang string, categoryIDs []uint, tagNames []string, publishedAt, unpublishAt *time.Time, newBlocks json.RawMessage, references map[string][]uint, changeMessage, expectedVersion string, actorID uint) (*models.Content, error) {
 	var content models.Content
+	req := &models.ContentUpdateRequest{
+		Title:         newTitle,
+		Slug:          newSlug,
+		Body:          newBody,
+		Blocks:        newBlocks,
+		Type:          newType,
+		Attributes:    newAttributes,
+		Status:        newStatus,
+		Language:      newLang,
+		CategoryIDs:   categoryIDs,
+		Tags:          tagNames,
+		PublishedAt:   publishedAt,
+		UnpublishAt:   unpublishAt,
+		References:    references,
+		ChangeMessage: changeMessage,
+	}
 
 	// Transaction guarantees atomicity
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
@@ -157,82 +328,158 @@ func UpdateContent(id uint, newTitle, newBody, newType, newAttributes, newStatus
 			return err
 		}
 
-		// 2. Create a snapshot (Version History)
-		versionSnapshot := models.ContentVersion{
-			ContentID:  content.ID,
-			Title:      content.Title,
-			Body:       content.Body,
-			Type:       content.Type,
-			Attributes: content.Attributes,
-			Status:     content.Status,
-			Language:   content.Language,
-			Blocks:     content.Blocks,
-			Version:    content.Version,
-			ChangedAt:  time.Now(),
+		if err := checkVersion(tx, &content, expectedVersion); err != nil {
+			return err
 		}
 
-		if err := tx.Create(&versionSnapshot).Error; err != nil {
+		drafted, err := editsDraft(tx, &content)
+		if err != nil {
+			return err
+		}
+		if drafted {
+			return saveDraft(tx, &content, req, actorID)
+		}
+		transition, entry, err := applyUpdate(tx, &content, req, actorID)
+		if err != nil {
 			return err
 		}
 
-		// 3. Update the content and increment version
-		content.Title = newTitle
-		content.Body = newBody
-		content.Type = newType
-		content.Attributes = newAttributes
-		content.Status = newStatus
-		if len(newBlocks) > 0 {
-			content.Blocks = datatypes.JSON(newBlocks)
+		// Trigger Webhook
+		if err := TriggerWebhooks(tx, "content.update", content); err != nil {
+			return err
 		}
-		if newLang != "" {
-			content.Language = newLang
+		if transition == nil {
+			return nil
 		}
-		if publishedAt != nil {
-			content.PublishedAt = publishedAt
+		return notifyTransition(tx, content, transition, entry)
+	})
+	if err == nil {
+		notifySaved(content.ID, actorID)
+	}
+
+	return &content, err
+}
+
+// applyUpdate snapshots content and writes the changes in req as its next version
+func applyUpdate(tx *gorm.DB, content *models.Content, req *models.ContentUpdateRequest, actorID uint) (*models.WorkflowTransition, *models.ContentTransition, error) {
+	var transition *models.WorkflowTransition
+	var entry *models.ContentTransition
+	newStatus := strings.ToUpper(req.Status)
+
+	if err := ValidateAttributes(tx, req.Type, req.Attributes); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateBlocks(tx, req.Blocks); err != nil {
+		return nil, nil, err
+	}
+
+	fromStatus := content.Status
+	if newStatus != "" && newStatus != fromStatus {
+		var err error
+		// The update creates a new version, which has no approvals yet
+		if transition, err = authorizeTransition(tx, content.ID, content.Version+1, fromStatus, newStatus, actorID); err != nil {
+			return nil, nil, err
 		}
-		content.Version = content.Version + 1
+	}
 
-		if err := tx.Save(&content).Error; err != nil {
-			return err
+	if req.Slug != "" || req.Language != "" {
+		slug, lang := content.Slug, content.Language
+		if req.Slug != "" {
+			slug = req.Slug
+		}
+		if req.Language != "" {
+			lang = req.Language
+		}
+		if err := changeSlug(tx, content, slug, lang); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		// 4. Update Taxonomies
-		// Categories
-		if len(categoryIDs) > 0 {
-			var categories []models.Category
-			if err := tx.Where("id IN ?", categoryIDs).Find(&categories).Error; err != nil {
-				return err
-			}
-			if err := tx.Model(&content).Association("Categories").Replace(categories); err != nil {
-				return err
-			}
+	// 2. Create a snapshot (Version History)
+	if err := snapshotContent(tx, content); err != nil {
+		return nil, nil, err
+	}
+
+	// 3. Update the content and increment version
+	content.Title = req.Title
+	if req.Slug != "" {
+		content.Slug = req.Slug
+	}
+	content.Body = req.Body
+	content.Type = req.Type
+	content.Attributes = req.Attributes
+	if transition != nil {
+		if err := claimStatus(tx, content.ID, fromStatus, newStatus); err != nil {
+			return nil, nil, err
 		}
+		content.Status = newStatus
+	}
+	if len(req.Blocks) > 0 {
+		content.Blocks = datatypes.JSON(req.Blocks)
+	}
+	if req.Language != "" {
+		content.Language = req.Language
+	}
+	if req.PublishedAt != nil {
+		content.PublishedAt = req.PublishedAt
+	}
+	if req.UnpublishAt != nil {
+		content.UnpublishAt = req.UnpublishAt
+	}
+	if err := checkSchedule(content, transition != nil); err != nil {
+		return nil, nil, err
+	}
+	stampPublishedAt(content)
+	if err := bumpVersion(tx, content); err != nil {
+		return nil, nil, err
+	}
+	content.UpdatedByID = actorID
+	content.ChangeMessage = req.ChangeMessage
 
-		// Tags
-		if len(tagNames) > 0 {
-			// Logic to sync tags inside transaction
-			var tags []models.Tag
-			for _, name := range tagNames {
-				var tag models.Tag
-				if err := tx.FirstOrCreate(&tag, models.Tag{Name: name, Slug: name}).Error; err != nil {
-					return err
-				}
-				tags = append(tags, tag)
-			}
-			if err := tx.Model(&content).Association("Tags").Replace(tags); err != nil {
-				return err
-			}
+	if err := tx.Save(content).Error; err != nil {
+		return nil, nil, err
+	}
+	if transition != nil {
+		var err error
+		if entry, err = recordTransition(tx, content, transition, fromStatus, actorID, ""); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		return nil
-	})
+	if err := pruneReferences(tx, content); err != nil {
+		return nil, nil, err
+	}
+	if err := setReferences(tx, content, req.References); err != nil {
+		return nil, nil, err
+	}
+	if err := indexContent(tx, content); err != nil {
+		return nil, nil, err
+	}
 
-	// Trigger Webhook
-	if err == nil {
-		TriggerWebhooks("content.update", content)
+	// 4. Update Taxonomies
+	// Categories
+	if len(req.CategoryIDs) > 0 {
+		var categories []models.Category
+		if err := tx.Where("id IN ?", req.CategoryIDs).Find(&categories).Error; err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Model(content).Association("Categories").Replace(categories); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return &content, err
+	// Tags
+	if len(req.Tags) > 0 {
+		tags, err := syncTags(tx, req.Tags)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Model(content).Association("Tags").Replace(tags); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return transition, entry, nil
 }
 
 func GetContentHistory(contentID uint) ([]models.ContentVersion, error) {
@@ -241,7 +488,71 @@ func GetContentHistory(contentID uint) ([]models.ContentVersion, error) {
 	return history, err
 }
 
-func RevertContent(contentID uint, targetVersion int) (*models.Content, error) {
+// contentTaxonomies returns the category IDs and tag names of a content item as JSON
+// arrays, as kept in versions and drafts
+func contentTaxonomies(tx *gorm.DB, contentID uint) (datatypes.JSON, datatypes.JSON, error) {
+	categoryIDs := []uint{}
+	if err := tx.Table("content_categories").Where("content_id = ?", contentID).
+		Order("category_id").Pluck("category_id", &categoryIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	tagNames := []string{}
+	if err := tx.Model(&models.Tag{}).
+		Joins("JOIN content_tags ON content_tags.tag_id = tags.id").
+		Where("content_tags.content_id = ?", contentID).
+		Order("tags.name").Pluck("tags.name", &tagNames).Error; err != nil {
+		return nil, nil, err
+	}
+	categoriesJSON, err := json.Marshal(categoryIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	tagsJSON, err := json.Marshal(tagNames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return datatypes.JSON(categoriesJSON), datatypes.JSON(tagsJSON), nil
+}
+
+// snapshotContent saves the current state of content, taxonomies and references
+// included, to its version history
+func snapshotContent(tx *gorm.DB, content *models.Content) error {
+	categoryIDs, tags, err := contentTaxonomies(tx, content.ID)
+	if err != nil {
+		return err
+	}
+	references, err := contentReferences(tx, content.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ContentVersion{
+		ContentID:     content.ID,
+		Title:         content.Title,
+		Slug:          content.Slug,
+		Body:          content.Body,
+		Type:          content.Type,
+		Attributes:    content.Attributes,
+		Status:        content.Status,
+		Language:      content.Language,
+		PublishedAt:   content.PublishedAt,
+		UnpublishAt:   content.UnpublishAt,
+		CategoryIDs:   categoryIDs,
+		Tags:          tags,
+		References:    references,
+		Blocks:        content.Blocks,
+		Version:       content.Version,
+		UpdatedByID:   content.UpdatedByID,
+		ChangeMessage: content.ChangeMessage,
+		ChangedAt:     time.Now(),
+	}).Error
+}
+
+// RevertContent restores the state saved in a version as a new version made by actorID.
+// Content that is edited through its draft (see UpdateContent) gets the state in its
+// draft instead, so the live revision is only replaced on publish. changeMessage defaults
+// to "Reverted to version N". A non-empty expectedVersion must match the current VersionTag.
+func RevertContent(contentID uint, targetVersion int, changeMessage, expectedVersion string, actorID uint) (*models.Content, error) {
 	var content models.Content
 	var versionSnapshot models.ContentVersion
 
@@ -250,69 +561,289 @@ func RevertContent(contentID uint, targetVersion int) (*models.Content, error) {
 		if err := tx.First(&content, contentID).Error; err != nil {
 			return err
 		}
+		if err := checkVersion(tx, &content, expectedVersion); err != nil {
+			return err
+		}
 
 		// Find the target version
 		if err := tx.Where("content_id = ? AND version = ?", contentID, targetVersion).First(&versionSnapshot).Error; err != nil {
 			return errors.New("version not found")
 		}
+		if changeMessage == "" {
+			changeMessage = "Reverted to version " + strconv.Itoa(targetVersion)
+		}
 
-		// Save CURRENT state as history before reverting (so we don't lose the "bad" state)
-		currentSnapshot := models.ContentVersion{
-			ContentID:  content.ID,
-			Title:      content.Title,
-			Body:       content.Body,
-			Type:       content.Type,
-			Attributes: content.Attributes,
-			Status:     content.Status,
-			Blocks:     content.Blocks,
-			Version:    content.Version,
-			ChangedAt:  time.Now(),
+		drafted, err := editsDraft(tx, &content)
+		if err != nil {
+			return err
+		}
+		if drafted {
+			req, err := revertChanges(tx, &versionSnapshot, changeMessage)
+			if err != nil {
+				return err
+			}
+			return saveDraft(tx, &content, req, actorID)
+		}
+
+		// The content type and block types may have changed since the snapshot was taken
+		if err := ValidateAttributes(tx, versionSnapshot.Type, versionSnapshot.Attributes); err != nil {
+			return err
+		}
+		if err := ValidateBlocks(tx, versionSnapshot.Blocks); err != nil {
+			return err
 		}
-		if err := tx.Create(&currentSnapshot).Error; err != nil {
+
+		// Save CURRENT state as history before reverting (so we don't lose the "bad" state)
+		if err := snapshotContent(tx, &content); err != nil {
 			return err
 		}
 
 		// Revert Content to Snapshot Data
-		// Note: We increment the version number to indicate a new change (the revert itself is a change)
+		// Note: We increment the version number to indicate a new change (the revert itself is a change).
+		// The workflow status is kept; it only changes through transitions.
 		content.Title = versionSnapshot.Title
 		content.Body = versionSnapshot.Body
 		content.Type = versionSnapshot.Type
 		content.Attributes = versionSnapshot.Attributes
-		content.Status = versionSnapshot.Status
 		content.Blocks = versionSnapshot.Blocks
-		content.Version = content.Version + 1
+
+		// Older snapshots did not record these; the content keeps its current values then
+		if versionSnapshot.Slug != "" {
+			lang := versionSnapshot.Language
+			if lang == "" {
+				lang = content.Language
+			}
+			if err := changeSlug(tx, &content, versionSnapshot.Slug, lang); err != nil {
+				return err
+			}
+			content.Slug = versionSnapshot.Slug
+			content.Language = lang
+			content.PublishedAt = versionSnapshot.PublishedAt
+			content.UnpublishAt = versionSnapshot.UnpublishAt
+			if err := checkSchedule(&content, false); err != nil {
+				return err
+			}
+			stampPublishedAt(&content)
+		}
+
+		if err := bumpVersion(tx, &content); err != nil {
+			return err
+		}
+		content.UpdatedByID = actorID
+		content.ChangeMessage = changeMessage
 
 		if err := tx.Save(&content).Error; err != nil {
 			return err
 		}
-		return nil
+		if err := restoreTaxonomies(tx, &content, &versionSnapshot); err != nil {
+			return err
+		}
+		if err := pruneReferences(tx, &content); err != nil {
+			return err
+		}
+		if err := restoreReferences(tx, &content, &versionSnapshot); err != nil {
+			return err
+		}
+		if err := indexContent(tx, &content); err != nil {
+			return err
+		}
+		return TriggerWebhooks(tx, "content.update", content)
 	})
 
+	if err == nil {
+		notifySaved(content.ID, actorID)
+	}
 	return &content, err
 }
 
-func PublishScheduledContent() {
+// revertChanges turns a snapshot into the draft update that reverts to it. Fields older
+// snapshots did not record are left out, so the draft keeps its values for them.
+func revertChanges(tx *gorm.DB, snapshot *models.ContentVersion, changeMessage string) (*models.ContentUpdateRequest, error) {
+	req := &models.ContentUpdateRequest{
+		Title:         snapshot.Title,
+		Slug:          snapshot.Slug,
+		Body:          snapshot.Body,
+		Blocks:        json.RawMessage(snapshot.Blocks),
+		Type:          snapshot.Type,
+		Attributes:    snapshot.Attributes,
+		Language:      snapshot.Language,
+		ChangeMessage: changeMessage,
+	}
+	if snapshot.Slug != "" {
+		req.PublishedAt = snapshot.PublishedAt
+		req.UnpublishAt = snapshot.UnpublishAt
+	}
+	if err := unmarshalSnapshot(snapshot.CategoryIDs, &req.CategoryIDs); err != nil {
+		return nil, err
+	}
+	if err := unmarshalSnapshot(snapshot.Tags, &req.Tags); err != nil {
+		return nil, err
+	}
+	refs, err := snapshotReferences(tx, snapshot.Type, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	req.References = refs
+	return req, nil
+}
+
+// restoreTaxonomies sets the categories and tags saved in a snapshot. Categories deleted
+// since are skipped; deleted tags are created again.
+func restoreTaxonomies(tx *gorm.DB, content *models.Content, snapshot *models.ContentVersion) error {
+	var categoryIDs []uint
+	if err := unmarshalSnapshot(snapshot.CategoryIDs, &categoryIDs); err != nil {
+		return err
+	}
+	if categoryIDs != nil {
+		var categories []models.Category
+		if err := tx.Where("id IN ?", categoryIDs).Find(&categories).Error; err != nil {
+			return err
+		}
+		categoriesAssoc := tx.Model(content).Association("Categories")
+		if len(categories) == 0 {
+			if err := categoriesAssoc.Clear(); err != nil {
+				return err
+			}
+		} else if err := categoriesAssoc.Replace(categories); err != nil {
+			return err
+		}
+	}
+
+	var tagNames []string
+	if err := unmarshalSnapshot(snapshot.Tags, &tagNames); err != nil {
+		return err
+	}
+	if tagNames != nil {
+		tags, err := syncTags(tx, tagNames)
+		if err != nil {
+			return err
+		}
+		tagsAssoc := tx.Model(content).Association("Tags")
+		if len(tags) == 0 {
+			if err := tagsAssoc.Clear(); err != nil {
+				return err
+			}
+		} else if err := tagsAssoc.Replace(tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalSnapshot leaves v nil when the snapshot did not record the field
+func unmarshalSnapshot(data datatypes.JSON, v interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// PublishScheduledContent publishes SCHEDULED content whose publish date has passed. Runs
+// as part of the ContentScheduleJob.
+func PublishScheduledContent() error {
 	var scheduledContents []models.Content
 	now := time.Now()
 
 	// Find contents that are SCHEDULED and PublishedAt <= Now
-	if err := database.DB.Where("status = ? AND published_at <= ?", "SCHEDULED", now).Find(&scheduledContents).Error; err != nil {
-		return
+	if err := database.DB.Where("status = ? AND published_at <= ?", models.StatusScheduled, now).Find(&scheduledContents).Error; err != nil {
+		return err
 	}
 
 	for _, content := range scheduledContents {
 		log.Printf("Publishing scheduled content ID: %d", content.ID)
-		content.Status = "PUBLISHED"
-		if err := database.DB.Save(&content).Error; err == nil {
-			TriggerWebhooks("content.published", content)
+		_, err := TransitionContent(content.ID, models.StatusPublished, "Scheduled publish", nil, systemActor)
+		if err != nil && !errors.Is(err, ErrStatusChanged) {
+			log.Printf("Failed to publish scheduled content ID %d: %v", content.ID, err)
 		}
 	}
+	return nil
 }
 
-func DeleteContent(id uint) error {
-	// GORM soft delete
-	if err := database.DB.Delete(&models.Content{}, id).Error; err != nil {
+// UnpublishExpiredContent archives PUBLISHED content whose UnpublishAt has passed and
+// announces it with a content.unpublished webhook. Visitors stop seeing the content at
+// UnpublishAt regardless (see Viewer.Scope); this moves it out of the PUBLISHED status.
+func UnpublishExpiredContent() error {
+	var expired []models.Content
+	if err := database.DB.Where("status = ? AND unpublish_at <= ?", models.StatusPublished, time.Now()).Find(&expired).Error; err != nil {
 		return err
 	}
+
+	for _, content := range expired {
+		log.Printf("Unpublishing expired content ID: %d", content.ID)
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			transition, entry, err := takeTransition(tx, &content, models.StatusArchived, "Embargo window ended", nil, systemActor)
+			if err != nil {
+				return err
+			}
+			if err := notifyTransition(tx, content, transition, entry); err != nil {
+				return err
+			}
+			return TriggerWebhooks(tx, "content.unpublished", content)
+		})
+		if errors.Is(err, ErrStatusChanged) {
+			continue // Handled elsewhere in the meantime
+		}
+		if err != nil {
+			log.Printf("Failed to unpublish content ID %d: %v", content.ID, err)
+		}
+	}
 	return nil
 }
+
+// DeleteContent soft deletes content and fires the "content.delete" webhook. A non-empty
+// expectedVersion must match the current VersionTag.
+func DeleteContent(id uint, expectedVersion string) error {
+	var content models.Content
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&content, id).Error; err != nil {
+			return err
+		}
+		if err := checkVersion(tx, &content, expectedVersion); err != nil {
+			return err
+		}
+		// GORM soft delete
+		if err := tx.Delete(&content).Error; err != nil {
+			return err
+		}
+		if err := unindexContent(tx, id); err != nil {
+			return err
+		}
+		return TriggerWebhooks(tx, "content.delete", content)
+	})
+	if err == nil {
+		presence.Notify(id, DeletedEvent{Type: "deleted"})
+	}
+	return err
+}
+
+// GetTranslations returns the other language versions sharing the content's GroupID
+// that the viewer may see
+func GetTranslations(content *models.Content, viewer Viewer) ([]models.Content, error) {
+	var translations []models.Content
+	err := database.DB.Scopes(viewer.Scope).Where("group_id = ? AND id <> ?", content.GroupID, content.ID).Order("language asc").Find(&translations).Error
+	return translations, err
+}
+
+// GetTranslationsFor is the batch form of GetTranslations, keyed by content ID
+func GetTranslationsFor(contents []*models.Content, viewer Viewer) (map[uint][]models.Content, error) {
+	groups := make([]string, len(contents))
+	for i, c := range contents {
+		groups[i] = c.GroupID
+	}
+	var list []models.Content
+	if err := database.DB.Scopes(viewer.Scope).Where("group_id IN ?", groups).Order("language asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	byContent := make(map[uint][]models.Content, len(contents))
+	for _, c := range contents {
+		translations := []models.Content{}
+		for _, t := range list {
+			if t.GroupID == c.GroupID && t.ID != c.ID {
+				translations = append(translations, t)
+			}
+		}
+		byContent[c.ID] = translations
+	}
+	return byContent, nil
+}
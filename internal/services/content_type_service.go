@@ -0,0 +1,219 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/jsonschema"
+	"content-flow/internal/pkgs/validator"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var referenceFieldName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+func CreateContentType(name, description string, schema json.RawMessage, references []models.ReferenceField) (*models.ContentType, error) {
+	if err := checkContentTypeName(name, 0); err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := normalizeReferenceFields(references)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := &models.ContentType{
+		Name:        name,
+		Description: description,
+		Schema:      normalized,
+		References:  refs,
+	}
+	err = database.DB.Create(contentType).Error
+	return contentType, err
+}
+
+func GetAllContentTypes() ([]models.ContentType, error) {
+	var contentTypes []models.ContentType
+	err := database.DB.Order("name asc").Find(&contentTypes).Error
+	return contentTypes, err
+}
+
+func GetContentTypeByID(id uint) (*models.ContentType, error) {
+	var contentType models.ContentType
+	if err := database.DB.First(&contentType, id).Error; err != nil {
+		return nil, err
+	}
+	return &contentType, nil
+}
+
+func UpdateContentType(id uint, name, description string, schema json.RawMessage, references []models.ReferenceField) (*models.ContentType, error) {
+	contentType, err := GetContentTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkContentTypeName(name, id); err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalizeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := normalizeReferenceFields(references)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType.Name = name
+	contentType.Description = description
+	contentType.Schema = normalized
+	contentType.References = refs
+
+	err = database.DB.Save(contentType).Error
+	return contentType, err
+}
+
+// checkContentTypeName rejects a name already registered by another content type than id
+func checkContentTypeName(name string, id uint) error {
+	var count int64
+	if err := database.DB.Model(&models.ContentType{}).Where("name = ? AND id <> ?", name, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return invalidFilter("name", "A content type named "+name+" already exists")
+	}
+	return nil
+}
+
+// DeleteContentType removes the registration only; existing content keeps its Type string
+// and simply stops being validated.
+func DeleteContentType(id uint) error {
+	return database.DB.Delete(&models.ContentType{}, id).Error
+}
+
+// ValidateAttributes checks an Attributes payload against the schema registered for the
+// content type. Unregistered types are accepted as long as the payload is valid JSON.
+func ValidateAttributes(db *gorm.DB, typeName, attributes string) error {
+	raw := []byte(strings.TrimSpace(attributes))
+	if len(raw) == 0 {
+		raw = []byte("{}")
+	}
+	if !json.Valid(raw) {
+		return validator.NewValidationError([]*validator.ErrorResponse{
+			{Field: "attributes", Message: "Must be valid JSON"},
+		})
+	}
+
+	if typeName == "" {
+		return nil
+	}
+
+	var contentType models.ContentType
+	err := db.Where("name = ?", typeName).First(&contentType).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(contentType.Schema) == 0 {
+		return nil
+	}
+
+	schema, err := jsonschema.Parse(contentType.Schema)
+	if err != nil {
+		return err
+	}
+	return validator.NewValidationError(schema.ValidateJSON(raw, "attributes"))
+}
+
+// getReferenceFields returns the reference fields declared by a content type, keyed by name.
+// Unregistered types declare none.
+func getReferenceFields(db *gorm.DB, typeName string) (map[string]models.ReferenceField, error) {
+	fields := map[string]models.ReferenceField{}
+	if typeName == "" {
+		return fields, nil
+	}
+
+	var contentType models.ContentType
+	err := db.Where("name = ?", typeName).First(&contentType).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fields, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(contentType.References) == 0 {
+		return fields, nil
+	}
+
+	var list []models.ReferenceField
+	if err := json.Unmarshal(contentType.References, &list); err != nil {
+		return nil, err
+	}
+	for _, f := range list {
+		fields[f.Name] = f
+	}
+	return fields, nil
+}
+
+func normalizeReferenceFields(fields []models.ReferenceField) (datatypes.JSON, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	var errs []*validator.ErrorResponse
+	seen := map[string]bool{}
+	for i := range fields {
+		f := &fields[i]
+		path := fmt.Sprintf("references[%d]", i)
+		if f.Cardinality == "" {
+			f.Cardinality = models.ReferenceMany
+		}
+
+		if !referenceFieldName.MatchString(f.Name) {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".name", Message: "Must start with a letter and contain only letters, digits or '_'"})
+		} else if seen[f.Name] {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".name", Message: "Duplicate reference field " + f.Name})
+		}
+		seen[f.Name] = true
+
+		if f.Target != models.ReferenceTargetContent && f.Target != models.ReferenceTargetMedia {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".target", Message: "Must be one of: content, media"})
+		}
+		if f.Cardinality != models.ReferenceOne && f.Cardinality != models.ReferenceMany {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".cardinality", Message: "Must be one of: one, many"})
+		}
+		if f.Target == models.ReferenceTargetMedia && len(f.ContentTypes) > 0 {
+			errs = append(errs, &validator.ErrorResponse{Field: path + ".content_types", Message: "Only allowed for content targets"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, validator.NewValidationError(errs)
+	}
+
+	raw, err := json.Marshal(fields)
+	return datatypes.JSON(raw), err
+}
+
+// normalizeSchema rejects schemas the validator cannot enforce, so a broken registration
+// fails at write time instead of on every content save.
+func normalizeSchema(schema json.RawMessage) (datatypes.JSON, error) {
+	if len(schema) == 0 || string(schema) == "null" {
+		return nil, nil
+	}
+	if _, err := jsonschema.Parse(schema); err != nil {
+		return nil, validator.NewValidationError([]*validator.ErrorResponse{
+			{Field: "schema", Message: err.Error()},
+		})
+	}
+	return datatypes.JSON(schema), nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/diff"
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// FieldChange is a scalar field that differs between two versions
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// BodyDiff is the body diff line by line and, for finer review, word by word
+type BodyDiff struct {
+	Lines []diff.Op `json:"lines"`
+	Words []diff.Op `json:"words"`
+}
+
+// ContentDiff lists what changed from one version of a content item to another. Only
+// changed fields and blocks are included; Body is nil when the body is unchanged.
+type ContentDiff struct {
+	ContentID  uint               `json:"content_id"`
+	From       int                `json:"from"`
+	To         int                `json:"to"`
+	Fields     []FieldChange      `json:"fields"`
+	Attributes []diff.Change      `json:"attributes"`
+	Blocks     []diff.BlockChange `json:"blocks"`
+	Body       *BodyDiff          `json:"body,omitempty"`
+}
+
+// DiffContent compares two versions of a content item. to defaults to the current version.
+func DiffContent(contentID uint, from, to int) (*ContentDiff, error) {
+	var content models.Content
+	if err := database.DB.First(&content, contentID).Error; err != nil {
+		return nil, err
+	}
+	if from <= 0 {
+		return nil, invalidFilter("from", "from must be a version number")
+	}
+	if to == 0 {
+		to = content.Version
+	}
+
+	old, err := contentAtVersion(&content, from, "from")
+	if err != nil {
+		return nil, err
+	}
+	cur, err := contentAtVersion(&content, to, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContentDiff{ContentID: content.ID, From: from, To: to, Fields: []FieldChange{}}
+	for _, f := range []FieldChange{
+		{Field: "title", Old: old.Title, New: cur.Title},
+		{Field: "type", Old: old.Type, New: cur.Type},
+		{Field: "status", Old: old.Status, New: cur.Status},
+		{Field: "language", Old: old.Language, New: cur.Language},
+	} {
+		if f.Old != f.New {
+			result.Fields = append(result.Fields, f)
+		}
+	}
+
+	// Attributes saved before validation existed may not be JSON; those are compared as text
+	result.Attributes, err = diff.JSON([]byte(old.Attributes), []byte(cur.Attributes))
+	if err != nil {
+		result.Attributes = diff.Values(old.Attributes, cur.Attributes)
+	}
+	if result.Blocks, err = diff.Blocks(old.Blocks, cur.Blocks); err != nil {
+		return nil, err
+	}
+	if old.Body != cur.Body {
+		result.Body = &BodyDiff{
+			Lines: diff.Lines(old.Body, cur.Body),
+			Words: diff.Words(old.Body, cur.Body),
+		}
+	}
+	return result, nil
+}
+
+// contentAtVersion returns the content as it was at version, from its history snapshot
+// or, for the current version, the content itself
+func contentAtVersion(content *models.Content, version int, field string) (*models.ContentVersion, error) {
+	if version == content.Version {
+		return &models.ContentVersion{
+			ContentID:  content.ID,
+			Title:      content.Title,
+			Body:       content.Body,
+			Blocks:     content.Blocks,
+			Type:       content.Type,
+			Attributes: content.Attributes,
+			Status:     content.Status,
+			Language:   content.Language,
+			Version:    content.Version,
+		}, nil
+	}
+
+	var snapshot models.ContentVersion
+	err := database.DB.Where("content_id = ? AND version = ?", content.ID, version).
+		Order("id desc").
+		First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, invalidFilter(field, "Version "+strconv.Itoa(version)+" not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Language == "" {
+		snapshot.Language = content.Language
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrNoDraft is returned when publishing or discarding the draft of content that has none
+var ErrNoDraft = errors.New("content has no draft")
+
+// editsDraft reports whether edits to content go to its working draft: published content
+// and content whose draft was not published or discarded yet
+func editsDraft(tx *gorm.DB, content *models.Content) (bool, error) {
+	if content.Status == models.StatusPublished {
+		return true, nil
+	}
+	var count int64
+	err := tx.Model(&models.ContentDraft{}).Where("content_id = ?", content.ID).Count(&count).Error
+	return count > 0, err
+}
+
+// saveDraft applies the changes in req to the working draft of content, starting one from
+// the live revision when there is none, and overlays the draft on content. Fields follow
+// the UpdateContent rules, so omitted slug, blocks, taxonomies and dates are kept.
+func saveDraft(tx *gorm.DB, content *models.Content, req *models.ContentUpdateRequest, actorID uint) error {
+	if status := strings.ToUpper(req.Status); status != "" && status != content.Status {
+		return invalidFilter("status", "Content with a draft changes status through a workflow transition; publish or discard the draft to edit it directly")
+	}
+	if err := ValidateAttributes(tx, req.Type, req.Attributes); err != nil {
+		return err
+	}
+	if err := ValidateBlocks(tx, req.Blocks); err != nil {
+		return err
+	}
+
+	draft, err := loadDraft(tx, content)
+	if err != nil {
+		return err
+	}
+
+	draft.Title = req.Title
+	if req.Slug != "" {
+		draft.Slug = req.Slug
+	}
+	draft.Body = req.Body
+	draft.Type = req.Type
+	draft.Attributes = req.Attributes
+	if len(req.Blocks) > 0 {
+		draft.Blocks = datatypes.JSON(req.Blocks)
+	}
+	if req.Language != "" {
+		draft.Language = req.Language
+	}
+	if req.PublishedAt != nil {
+		draft.PublishedAt = req.PublishedAt
+	}
+	if req.UnpublishAt != nil {
+		draft.UnpublishAt = req.UnpublishAt
+	}
+	if len(req.CategoryIDs) > 0 {
+		if draft.CategoryIDs, err = json.Marshal(req.CategoryIDs); err != nil {
+			return err
+		}
+	}
+	if len(req.Tags) > 0 {
+		if draft.Tags, err = json.Marshal(req.Tags); err != nil {
+			return err
+		}
+	}
+	if len(req.References) > 0 {
+		references := map[string][]uint{}
+		if err := unmarshalSnapshot(draft.References, &references); err != nil {
+			return err
+		}
+		for field, ids := range req.References {
+			references[field] = ids
+		}
+		if draft.References, err = json.Marshal(references); err != nil {
+			return err
+		}
+	}
+	draft.UpdatedByID = actorID
+	draft.ChangeMessage = req.ChangeMessage
+
+	if draft.Slug != content.Slug || draft.Language != content.Language {
+		if err := checkContentSlug(tx, draft.Slug, draft.Language, content.ID); err != nil {
+			return err
+		}
+	}
+	if draft.UnpublishAt != nil && draft.PublishedAt != nil && !draft.UnpublishAt.After(*draft.PublishedAt) {
+		return invalidFilter("unpublish_at", "unpublish_at must be after published_at")
+	}
+
+	if draft.ID == 0 {
+		if draft.Version, err = firstDraftVersion(tx, content); err != nil {
+			return err
+		}
+	} else if err := bumpDraftVersion(tx, draft); err != nil {
+		return err
+	}
+	if err := tx.Save(draft).Error; err != nil {
+		return err
+	}
+	return overlayDraft(tx, content, draft)
+}
+
+// loadDraft returns the draft of content, or a new one holding its live revision
+func loadDraft(tx *gorm.DB, content *models.Content) (*models.ContentDraft, error) {
+	var draft models.ContentDraft
+	err := tx.Where("content_id = ?", content.ID).First(&draft).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return &draft, err
+	}
+
+	categoryIDs, tags, err := contentTaxonomies(tx, content.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ContentDraft{
+		ContentID:   content.ID,
+		BaseVersion: content.Version,
+		Title:       content.Title,
+		Slug:        content.Slug,
+		Body:        content.Body,
+		Blocks:      content.Blocks,
+		Type:        content.Type,
+		Attributes:  content.Attributes,
+		Language:    content.Language,
+		PublishedAt: content.PublishedAt,
+		UnpublishAt: content.UnpublishAt,
+		CategoryIDs: categoryIDs,
+		Tags:        tags,
+	}, nil
+}
+
+// overlayDraft replaces the fields of content with those of its draft. Tags the draft
+// introduces are listed by name only since they are created on publish.
+func overlayDraft(tx *gorm.DB, content *models.Content, draft *models.ContentDraft) error {
+	content.Title = draft.Title
+	content.Slug = draft.Slug
+	content.Body = draft.Body
+	content.Blocks = draft.Blocks
+	content.Type = draft.Type
+	content.Attributes = draft.Attributes
+	content.Language = draft.Language
+	content.PublishedAt = draft.PublishedAt
+	content.UnpublishAt = draft.UnpublishAt
+	content.UpdatedByID = draft.UpdatedByID
+	content.ChangeMessage = draft.ChangeMessage
+
+	var categoryIDs []uint
+	if err := unmarshalSnapshot(draft.CategoryIDs, &categoryIDs); err != nil {
+		return err
+	}
+	content.Categories = []models.Category{}
+	if err := tx.Where("id IN ?", categoryIDs).Find(&content.Categories).Error; err != nil {
+		return err
+	}
+
+	var tagNames []string
+	if err := unmarshalSnapshot(draft.Tags, &tagNames); err != nil {
+		return err
+	}
+	var existing []models.Tag
+	if err := tx.Where("name IN ?", tagNames).Find(&existing).Error; err != nil {
+		return err
+	}
+	content.Tags = []models.Tag{}
+	for _, name := range tagNames {
+		tag := models.Tag{Name: name}
+		for _, t := range existing {
+			if t.Name == name {
+				tag = t
+			}
+		}
+		content.Tags = append(content.Tags, tag)
+	}
+
+	content.Revision = models.RevisionDraft
+	content.HasDraft = true
+	return nil
+}
+
+// ApplyDraft replaces content with its draft revision. Content without a draft is left
+// as it is, since its live revision is also its latest.
+func ApplyDraft(content *models.Content) error {
+	var draft models.ContentDraft
+	err := database.DB.Where("content_id = ?", content.ID).First(&draft).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return overlayDraft(database.DB, content, &draft)
+}
+
+// PublishDraft makes the draft the live revision: it is saved as the next version, made
+// by actorID with the draft's change message, and the draft is removed. The workflow
+// status is left as it is. A non-empty expectedVersion must match the current VersionTag,
+// so a draft edited after it was reviewed is not published unseen.
+func PublishDraft(contentID uint, expectedVersion string, actorID uint) (*models.Content, error) {
+	var content models.Content
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&content, contentID).Error; err != nil {
+			return err
+		}
+		if err := checkVersion(tx, &content, expectedVersion); err != nil {
+			return err
+		}
+		if err := publishDraft(tx, &content, actorID); err != nil {
+			return err
+		}
+		return TriggerWebhooks(tx, "content.update", content)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifySaved(content.ID, actorID)
+	return &content, nil
+}
+
+// publishDraft writes the draft of loaded content as its next version and removes it.
+// The draft needs as many approvals as making content live in its status does (see
+// draftApprovals), given to this revision of the draft.
+func publishDraft(tx *gorm.DB, content *models.Content, actorID uint) error {
+	var draft models.ContentDraft
+	if err := tx.Where("content_id = ?", content.ID).First(&draft).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoDraft
+		}
+		return err
+	}
+
+	if actorID != systemActor {
+		required, err := draftApprovals(tx, content.Status)
+		if err != nil {
+			return err
+		}
+		if required > 0 {
+			approvals, err := countDraftApprovals(tx, content.ID, content.Version, draft.Version)
+			if err != nil {
+				return err
+			}
+			if approvals < int64(required) {
+				return invalidFilter("draft", fmt.Sprintf("Publishing the draft of %s content needs %d approval(s) of version %s, has %d",
+					content.Status, required, VersionTag(content.Version, draft.Version), approvals))
+			}
+		}
+	}
+
+	req, err := draftChanges(&draft)
+	if err != nil {
+		return err
+	}
+	if _, _, err := applyUpdate(tx, content, req, actorID); err != nil {
+		return err
+	}
+	return tx.Delete(&draft).Error
+}
+
+// DiscardDraft drops the unpublished edits of a content item on behalf of actorID. Its
+// number is kept on the content so the next draft does not get the same version tag.
+func DiscardDraft(contentID uint, actorID uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var draft models.ContentDraft
+		if err := tx.Where("content_id = ?", contentID).First(&draft).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoDraft
+			}
+			return err
+		}
+		if err := tx.Delete(&draft).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Content{}).Where("id = ? AND last_draft_version < ?", contentID, draft.Version).
+			UpdateColumn("last_draft_version", draft.Version).Error
+	})
+	if err != nil {
+		return err
+	}
+	notifySaved(contentID, actorID)
+	return nil
+}
+
+// draftChanges turns a draft into the update that publishes it
+func draftChanges(draft *models.ContentDraft) (*models.ContentUpdateRequest, error) {
+	req := &models.ContentUpdateRequest{
+		Title:         draft.Title,
+		Slug:          draft.Slug,
+		Body:          draft.Body,
+		Blocks:        json.RawMessage(draft.Blocks),
+		Type:          draft.Type,
+		Attributes:    draft.Attributes,
+		Language:      draft.Language,
+		PublishedAt:   draft.PublishedAt,
+		UnpublishAt:   draft.UnpublishAt,
+		ChangeMessage: draft.ChangeMessage,
+	}
+	if err := unmarshalSnapshot(draft.CategoryIDs, &req.CategoryIDs); err != nil {
+		return nil, err
+	}
+	if err := unmarshalSnapshot(draft.Tags, &req.Tags); err != nil {
+		return nil, err
+	}
+	if err := unmarshalSnapshot(draft.References, &req.References); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"content-flow/internal/database"
 	"content-flow/internal/models"
+	"content-flow/internal/pkgs/pagination"
 	"errors"
 
 	"gorm.io/gorm"
@@ -35,6 +36,46 @@ func GetComments(contentID uint) ([]models.Comment, error) {
 	return comments, nil
 }
 
+// GetCommentsFor is the batch form of GetComments, keyed by content ID
+func GetCommentsFor(contentIDs []uint) (map[uint][]models.Comment, error) {
+	var comments []models.Comment
+	if err := database.DB.Where("content_id IN ?", contentIDs).Preload("User").Order("created_at desc").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	byContent := make(map[uint][]models.Comment, len(contentIDs))
+	for _, id := range contentIDs {
+		byContent[id] = []models.Comment{}
+	}
+	for _, c := range comments {
+		byContent[c.ContentID] = append(byContent[c.ContentID], c)
+	}
+	return byContent, nil
+}
+
+// GetCommentsPage is the keyset paginated form of GetComments
+func GetCommentsPage(contentID uint, p pagination.Params) ([]models.Comment, pagination.Page, error) {
+	var comments []models.Comment
+	var page pagination.Page
+	p.Normalize()
+
+	query := database.DB.Model(&models.Comment{}).Where("content_id = ?", contentID)
+	if !p.SkipTotal {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, page, err
+		}
+		page.HasTotal = true
+	}
+
+	query = query.Preload("User")
+	if err := query.Scopes(pagination.Newest("comments", p.Cursor, p.Limit)).Find(&comments).Error; err != nil {
+		return nil, page, err
+	}
+	comments, page.NextCursor = pagination.Trim(comments, p.Limit, func(c models.Comment) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	})
+	return comments, page, nil
+}
+
 // ToggleLike adds a like if not exists, removes it if it does.
 // Returns true if liked (added), false if unliked (removed).
 func ToggleLike(userID, contentID uint) (bool, error) {
@@ -69,3 +110,24 @@ func GetLikeCount(contentID uint) (int64, error) {
 	}
 	return count, nil
 }
+
+// GetLikeCounts is the batch form of GetLikeCount, keyed by content ID
+func GetLikeCounts(contentIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		ContentID uint
+		Count     int64
+	}
+	err := database.DB.Model(&models.Like{}).Select("content_id, COUNT(*) AS count").
+		Where("content_id IN ?", contentIDs).Group("content_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(contentIDs))
+	for _, id := range contentIDs {
+		counts[id] = 0
+	}
+	for _, r := range rows {
+		counts[r.ContentID] = r.Count
+	}
+	return counts, nil
+}
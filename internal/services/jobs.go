@@ -0,0 +1,32 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/queue"
+	"context"
+	"time"
+)
+
+// ContentScheduleJob is the recurring job that publishes scheduled content and archives
+// expired content
+const ContentScheduleJob = "content.schedule"
+
+// RegisterJobs installs the background job handlers; call before queue.Start
+func RegisterJobs() {
+	queue.Register(WebhookDeliveryJob, queue.Options{Concurrency: 4, Lease: 30 * time.Second, MaxAttempts: 8}, deliverWebhook)
+	queue.Register(ContentScheduleJob, queue.Options{Every: time.Minute, MaxAttempts: 3}, runContentSchedule)
+}
+
+// runContentSchedule sweeps scheduled content. When several instances run, the lock keeps
+// sweeps from overlapping; each item is still only moved once because status changes
+// are conditional (see claimStatus).
+func runContentSchedule(ctx context.Context, job *models.Job) error {
+	_, err := database.TryLock(ContentScheduleJob, 5*time.Minute, func() error {
+		if err := PublishScheduledContent(); err != nil {
+			return err
+		}
+		return UnpublishExpiredContent()
+	})
+	return err
+}
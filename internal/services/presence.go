@@ -0,0 +1,45 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/presence"
+	"log"
+)
+
+// VersionEvent tells the editors in the presence room of a content item which version
+// tag to base their writes on: when they join, and whenever someone saves
+type VersionEvent struct {
+	Type       string `json:"type"` // "version"
+	Version    int    `json:"version"`
+	VersionTag string `json:"version_tag"`
+	UserID     uint   `json:"user_id,omitempty"` // Who saved; unset when joining
+}
+
+// DeletedEvent tells the presence room that its content item was deleted
+type DeletedEvent struct {
+	Type string `json:"type"` // "deleted"
+}
+
+// CurrentVersion returns the VersionEvent a client joining the room of a content item
+// starts from
+func CurrentVersion(contentID uint) (VersionEvent, error) {
+	var content models.Content
+	if err := database.DB.Select("id", "version").First(&content, contentID).Error; err != nil {
+		return VersionEvent{}, err
+	}
+	tag, err := ContentVersionTag(&content)
+	return VersionEvent{Type: "version", Version: content.Version, VersionTag: tag}, err
+}
+
+// notifySaved tells the presence room of a content item that actorID saved it. The
+// version is read again, so the event carries the tag writes have to match now.
+func notifySaved(contentID uint, actorID uint) {
+	event, err := CurrentVersion(contentID)
+	if err != nil {
+		log.Printf("Failed to notify editors of content ID %d: %v", contentID, err)
+		return
+	}
+	event.UserID = actorID
+	presence.Notify(contentID, event)
+}
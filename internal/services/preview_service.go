@@ -0,0 +1,76 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/auth"
+	"errors"
+	"time"
+)
+
+// DefaultPreviewTTL is how long a preview link works when no expiry is requested
+const DefaultPreviewTTL = 24 * time.Hour
+
+// CreatePreviewToken mints a preview token for the content, optionally pinned to one of
+// its versions
+func CreatePreviewToken(contentID uint, version int, ttl time.Duration) (string, time.Time, error) {
+	content, err := GetContentByID(contentID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if version > 0 && version != content.Version {
+		var count int64
+		if err := database.DB.Model(&models.ContentVersion{}).Where("content_id = ? AND version = ?", contentID, version).Count(&count).Error; err != nil {
+			return "", time.Time{}, err
+		}
+		if count == 0 {
+			return "", time.Time{}, invalidFilter("version", "Version not found")
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultPreviewTTL
+	}
+	return auth.GeneratePreviewToken(contentID, version, ttl)
+}
+
+// GetContentPreview loads content regardless of its status: with its working draft
+// applied when no version is pinned, as it is live for the current version, or with the
+// fields saved in that version's snapshot for an earlier one
+func GetContentPreview(id uint, version int, fields *ContentFields) (*models.Content, error) {
+	content, err := GetVisibleContent(id, allContent, fields)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		if err := ApplyDraft(content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
+	if version == content.Version {
+		return content, nil
+	}
+
+	var snapshot models.ContentVersion
+	if err := database.DB.Where("content_id = ? AND version = ?", id, version).First(&snapshot).Error; err != nil {
+		return nil, errors.New("version not found")
+	}
+	content.Title = snapshot.Title
+	content.Body = snapshot.Body
+	content.Type = snapshot.Type
+	content.Attributes = snapshot.Attributes
+	content.Status = snapshot.Status
+	content.Blocks = snapshot.Blocks
+	content.Version = snapshot.Version
+	if snapshot.Language != "" {
+		content.Language = snapshot.Language
+	}
+	if snapshot.Slug != "" {
+		content.Slug = snapshot.Slug
+		content.PublishedAt = snapshot.PublishedAt
+		content.UnpublishAt = snapshot.UnpublishAt
+	}
+	content.UpdatedByID = snapshot.UpdatedByID
+	content.ChangeMessage = snapshot.ChangeMessage
+	return content, nil
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/validator"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MaxIncludeDepth bounds how many levels of references ?include= may expand
+const MaxIncludeDepth = 3
+
+// setReferences validates and replaces the given reference fields of a content item.
+// Fields missing from refs keep their current values.
+func setReferences(tx *gorm.DB, content *models.Content, refs map[string][]uint) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	fields, err := getReferenceFields(tx, content.Type)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []*validator.ErrorResponse
+	for _, name := range names {
+		ids := refs[name]
+		path := "references." + name
+
+		def, ok := fields[name]
+		if !ok {
+			errs = append(errs, &validator.ErrorResponse{Field: path, Message: "Unknown reference field for type " + content.Type})
+			continue
+		}
+		if def.Cardinality == models.ReferenceOne && len(ids) > 1 {
+			errs = append(errs, &validator.ErrorResponse{Field: path, Message: "Accepts a single reference"})
+			continue
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		switch def.Target {
+		case models.ReferenceTargetContent:
+			var targets []models.Content
+			if err := tx.Select("id", "type").Where("id IN ?", ids).Find(&targets).Error; err != nil {
+				return err
+			}
+			types := make(map[uint]string, len(targets))
+			for _, t := range targets {
+				types[t.ID] = t.Type
+			}
+			for _, id := range ids {
+				t, found := types[id]
+				if !found {
+					errs = append(errs, &validator.ErrorResponse{Field: path, Message: fmt.Sprintf("Content %d not found", id)})
+				} else if len(def.ContentTypes) > 0 && !containsString(def.ContentTypes, t) {
+					errs = append(errs, &validator.ErrorResponse{Field: path, Message: fmt.Sprintf("Content %d must be of type: %s", id, strings.Join(def.ContentTypes, ", "))})
+				}
+			}
+		case models.ReferenceTargetMedia:
+			var found []uint
+			if err := tx.Model(&models.Media{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if !containsUint(found, id) {
+					errs = append(errs, &validator.ErrorResponse{Field: path, Message: fmt.Sprintf("Media %d not found", id)})
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return validator.NewValidationError(errs)
+	}
+
+	for _, name := range names {
+		if err := tx.Where("content_id = ? AND field = ?", content.ID, name).Delete(&models.ContentReference{}).Error; err != nil {
+			return err
+		}
+		for i, id := range refs[name] {
+			ref := models.ContentReference{
+				ContentID:  content.ID,
+				Field:      name,
+				TargetType: fields[name].Target,
+				TargetID:   id,
+				Position:   i,
+			}
+			if err := tx.Create(&ref).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pruneReferences drops values of fields the content's (possibly changed) type no longer declares
+func pruneReferences(tx *gorm.DB, content *models.Content) error {
+	fields, err := getReferenceFields(tx, content.Type)
+	if err != nil {
+		return err
+	}
+	query := tx.Where("content_id = ?", content.ID)
+	if len(fields) > 0 {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		query = query.Where("field NOT IN ?", names)
+	}
+	return query.Delete(&models.ContentReference{}).Error
+}
+
+// contentReferences returns the reference field values of a content item as a JSON
+// object of field name -> target IDs, as kept in versions
+func contentReferences(tx *gorm.DB, contentID uint) (datatypes.JSON, error) {
+	var refs []models.ContentReference
+	if err := tx.Where("content_id = ?", contentID).Order("field, position").Find(&refs).Error; err != nil {
+		return nil, err
+	}
+	values := map[string][]uint{}
+	for _, ref := range refs {
+		values[ref.Field] = append(values[ref.Field], ref.TargetID)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(data), nil
+}
+
+// restoreReferences sets the reference values saved in a snapshot, clearing fields it has
+// no values for
+func restoreReferences(tx *gorm.DB, content *models.Content, snapshot *models.ContentVersion) error {
+	refs, err := snapshotReferences(tx, content.Type, snapshot)
+	if err != nil {
+		return err
+	}
+	return setReferences(tx, content, refs)
+}
+
+// snapshotReferences returns the reference values saved in a snapshot for every field
+// of typeName, empty for fields it has none for, or nil when the snapshot predates
+// references. Targets deleted since and fields the type no longer declares are skipped.
+func snapshotReferences(tx *gorm.DB, typeName string, snapshot *models.ContentVersion) (map[string][]uint, error) {
+	var saved map[string][]uint
+	if err := unmarshalSnapshot(snapshot.References, &saved); err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, nil
+	}
+
+	fields, err := getReferenceFields(tx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string][]uint, len(fields))
+	for name, def := range fields {
+		ids := saved[name]
+		if len(ids) > 0 {
+			var found []uint
+			query := tx.Model(&models.Content{})
+			if def.Target == models.ReferenceTargetMedia {
+				query = tx.Model(&models.Media{})
+			}
+			if err := query.Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+				return nil, err
+			}
+			kept := make([]uint, 0, len(ids))
+			for _, id := range ids {
+				if containsUint(found, id) {
+					kept = append(kept, id)
+				}
+			}
+			ids = kept
+		}
+		refs[name] = ids
+	}
+	return refs, nil
+}
+
+// includeTree is the parsed form of ?include=products.manufacturer,author_bio
+type includeTree map[string]includeTree
+
+// child returns the subtree for a field; "*" selects every field at its level
+func (t includeTree) child(field string) (includeTree, bool) {
+	if sub, ok := t[field]; ok {
+		return sub, true
+	}
+	sub, ok := t["*"]
+	return sub, ok
+}
+
+func parseInclude(include string) (includeTree, error) {
+	tree := includeTree{}
+	for _, path := range strings.Split(include, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		parts := strings.Split(path, ".")
+		if len(parts) > MaxIncludeDepth {
+			return nil, validator.NewValidationError([]*validator.ErrorResponse{
+				{Field: "include", Message: fmt.Sprintf("%s exceeds the maximum depth of %d", path, MaxIncludeDepth)},
+			})
+		}
+		node := tree
+		for _, p := range parts {
+			if node[p] == nil {
+				node[p] = includeTree{}
+			}
+			node = node[p]
+		}
+	}
+	return tree, nil
+}
+
+// LoadReferences fills Content.References for each item. Fields named in include are
+// expanded into the referenced Content/Media (recursively for dotted paths); all other
+// fields are returned as IDs. A content item is never expanded inside itself, so cycles
+// end with the ID of the item that closes the loop. Only content visible to viewer is expanded.
+func LoadReferences(contents []*models.Content, include string, viewer Viewer) error {
+	if len(contents) == 0 {
+		return nil
+	}
+	tree, err := parseInclude(include)
+	if err != nil {
+		return err
+	}
+
+	r := &referenceResolver{db: database.DB, viewer: viewer, fields: map[string]map[string]models.ReferenceField{}}
+	paths := make([][]uint, len(contents))
+	for i, c := range contents {
+		paths[i] = []uint{c.ID}
+	}
+	return r.expand(contents, tree, paths)
+}
+
+type referenceResolver struct {
+	db     *gorm.DB
+	viewer Viewer                                      // Expanded content the viewer may not see is left out
+	fields map[string]map[string]models.ReferenceField // content type -> field name -> definition
+}
+
+type includeGroup struct {
+	items []*models.Content
+	paths [][]uint
+	tree  includeTree
+}
+
+// expand resolves one level of references for items; paths[i] holds the IDs of
+// items[i] and all of its ancestors and is used for cycle protection.
+func (r *referenceResolver) expand(items []*models.Content, tree includeTree, paths [][]uint) error {
+	ids := make([]uint, len(items))
+	for i, c := range items {
+		ids[i] = c.ID
+		if _, ok := r.fields[c.Type]; !ok {
+			fields, err := getReferenceFields(r.db, c.Type)
+			if err != nil {
+				return err
+			}
+			r.fields[c.Type] = fields
+		}
+	}
+
+	var refs []models.ContentReference
+	if err := r.db.Where("content_id IN ?", ids).Order("content_id, field, position").Find(&refs).Error; err != nil {
+		return err
+	}
+	byContent := map[uint][]models.ContentReference{}
+	var contentIDs, mediaIDs []uint
+	for _, ref := range refs {
+		byContent[ref.ContentID] = append(byContent[ref.ContentID], ref)
+		if _, ok := tree.child(ref.Field); !ok {
+			continue
+		}
+		if ref.TargetType == models.ReferenceTargetMedia {
+			mediaIDs = append(mediaIDs, ref.TargetID)
+		} else {
+			contentIDs = append(contentIDs, ref.TargetID)
+		}
+	}
+
+	targets := map[uint]models.Content{}
+	if len(contentIDs) > 0 {
+		var list []models.Content
+		if err := r.db.Scopes(r.viewer.Scope).Preload("Categories").Preload("Tags").Where("contents.id IN ?", contentIDs).Find(&list).Error; err != nil {
+			return err
+		}
+		for _, c := range list {
+			targets[c.ID] = c
+		}
+	}
+	media := map[uint]models.Media{}
+	if len(mediaIDs) > 0 {
+		var list []models.Media
+		if err := r.db.Where("id IN ?", mediaIDs).Find(&list).Error; err != nil {
+			return err
+		}
+		for _, m := range list {
+			media[m.ID] = m
+		}
+	}
+
+	groups := map[string]*includeGroup{}
+	for i, item := range items {
+		values := map[string][]interface{}{}
+		for _, ref := range byContent[item.ID] {
+			sub, expandIt := tree.child(ref.Field)
+			if !expandIt {
+				values[ref.Field] = append(values[ref.Field], ref.TargetID)
+				continue
+			}
+
+			if ref.TargetType == models.ReferenceTargetMedia {
+				if m, ok := media[ref.TargetID]; ok {
+					values[ref.Field] = append(values[ref.Field], m)
+				}
+				continue
+			}
+
+			if containsUint(paths[i], ref.TargetID) {
+				values[ref.Field] = append(values[ref.Field], ref.TargetID)
+				continue
+			}
+			target, ok := targets[ref.TargetID]
+			if !ok {
+				continue // Deleted since it was referenced, or not visible to the viewer
+			}
+			child := target
+			g := groups[ref.Field]
+			if g == nil {
+				g = &includeGroup{tree: sub}
+				groups[ref.Field] = g
+			}
+			g.items = append(g.items, &child)
+			g.paths = append(g.paths, append(append([]uint{}, paths[i]...), child.ID))
+			values[ref.Field] = append(values[ref.Field], &child)
+		}
+
+		item.References = map[string]interface{}{}
+		for name, def := range r.fields[item.Type] {
+			vals := values[name]
+			if def.Cardinality == models.ReferenceOne {
+				if len(vals) > 0 {
+					item.References[name] = vals[0]
+				} else {
+					item.References[name] = nil
+				}
+				continue
+			}
+			if vals == nil {
+				vals = []interface{}{}
+			}
+			item.References[name] = vals
+		}
+		if len(item.References) == 0 {
+			item.References = nil
+		}
+	}
+
+	for _, g := range groups {
+		if err := r.expand(g.items, g.tree, g.paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint(list []uint, id uint) bool {
+	for _, v := range list {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/blocks"
+	"content-flow/internal/pkgs/renderer"
+	"encoding/json"
+	"strings"
+)
+
+// RenderContent renders the content's Blocks in the requested format. Content created
+// before blocks existed only has a Body, which is rendered as paragraphs instead.
+func RenderContent(content *models.Content, format renderer.Format) (string, error) {
+	list, err := blocks.Parse(content.Blocks)
+	if err != nil {
+		return "", err
+	}
+
+	if len(list) == 0 && content.Body != "" {
+		for _, para := range strings.Split(strings.ReplaceAll(content.Body, "\r\n", "\n"), "\n\n") {
+			if para = strings.TrimSpace(para); para == "" {
+				continue
+			}
+			data, _ := json.Marshal(map[string]string{"text": para})
+			list = append(list, blocks.Block{Type: "paragraph", Data: data})
+		}
+	}
+
+	return renderer.RenderBlocks(list, format)
+}
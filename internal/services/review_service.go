@@ -0,0 +1,216 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/auth"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotReviewer is returned when a user decides on a review they are not assigned to
+	ErrNotReviewer = errors.New("not assigned to this review")
+	// ErrReviewForbidden is returned when a reviewer lacks the permission for their decision
+	ErrReviewForbidden = errors.New("missing permission for review decision")
+)
+
+// decisionPermissions are the permissions reviewers need to approve or to request changes
+var decisionPermissions = map[string]string{
+	models.ReviewApproved:         "content.approve",
+	models.ReviewChangesRequested: "content.review",
+}
+
+// RequestReview asks reviewers to review the current version of a content item. Reviewers
+// need content.approve, since their approvals count towards publishing. Drafts are moved
+// to IN_REVIEW when that transition is configured.
+func RequestReview(contentID, requesterID uint, reviewerIDs []uint, note string) (*models.ReviewRequest, error) {
+	var content models.Content
+	var request models.ReviewRequest
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&content, contentID).Error; err != nil {
+			return err
+		}
+
+		var reviewers []uint
+		for _, id := range reviewerIDs {
+			if id == requesterID {
+				return invalidFilter("reviewer_ids", "You cannot review your own request")
+			}
+			if !containsUint(reviewers, id) {
+				reviewers = append(reviewers, id)
+			}
+		}
+		var found int64
+		if err := tx.Model(&models.User{}).Where("id IN ?", reviewers).Count(&found).Error; err != nil {
+			return err
+		}
+		if found != int64(len(reviewers)) {
+			return invalidFilter("reviewer_ids", "Unknown reviewer")
+		}
+		for _, id := range reviewers {
+			allowed, err := auth.HasPermission(id, decisionPermissions[models.ReviewApproved])
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return invalidFilter("reviewer_ids", "Reviewer "+strconv.Itoa(int(id))+" is missing permission content.approve")
+			}
+		}
+
+		drafts, err := draftVersion(tx, content.ID)
+		if err != nil {
+			return err
+		}
+		request = models.ReviewRequest{
+			ContentID:     content.ID,
+			Version:       content.Version,
+			DraftVersion:  drafts,
+			RequestedByID: requesterID,
+			Note:          note,
+			Status:        models.ReviewOpen,
+		}
+		for _, id := range reviewers {
+			request.Assignments = append(request.Assignments, models.ReviewAssignment{ReviewerID: id})
+		}
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+
+		if err := TriggerWebhooks(tx, "review.requested", request); err != nil {
+			return err
+		}
+
+		if content.Status != models.StatusDraft {
+			return nil
+		}
+		submit, err := hasTransition(tx, models.StatusDraft, models.StatusInReview)
+		if err != nil || !submit {
+			return err
+		}
+		transition, entry, err := takeTransition(tx, &content, models.StatusInReview, note, nil, requesterID)
+		if err != nil {
+			return err
+		}
+		return notifyTransition(tx, content, transition, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetReviewRequest(request.ID)
+}
+
+func GetReviewRequest(id uint) (*models.ReviewRequest, error) {
+	var request models.ReviewRequest
+	if err := database.DB.Preload("Assignments.Reviewer").First(&request, id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetContentReviews lists the review requests of a content item, newest first
+func GetContentReviews(contentID uint) ([]models.ReviewRequest, error) {
+	var requests []models.ReviewRequest
+	err := database.DB.Preload("Assignments.Reviewer").
+		Where("content_id = ?", contentID).
+		Order("id desc").
+		Find(&requests).Error
+	return requests, err
+}
+
+// GetAssignedReviews lists the open review requests still waiting for the reviewer's decision
+func GetAssignedReviews(reviewerID uint) ([]models.ReviewRequest, error) {
+	var requests []models.ReviewRequest
+	err := database.DB.Preload("Assignments.Reviewer").
+		Where("status = ?", models.ReviewOpen).
+		Where("id IN (?)", database.DB.Model(&models.ReviewAssignment{}).
+			Select("review_request_id").
+			Where("reviewer_id = ? AND decision = ?", reviewerID, "")).
+		Order("id asc").
+		Find(&requests).Error
+	return requests, err
+}
+
+// DecideReview records a reviewer's decision. Approving needs content.approve and
+// requesting changes content.review. Decisions can be changed until the content or its
+// draft moves on to a newer version.
+func DecideReview(requestID, reviewerID uint, decision, note string) (*models.ReviewRequest, error) {
+	var request models.ReviewRequest
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Assignments").First(&request, requestID).Error; err != nil {
+			return err
+		}
+
+		var assignment *models.ReviewAssignment
+		for i := range request.Assignments {
+			if request.Assignments[i].ReviewerID == reviewerID {
+				assignment = &request.Assignments[i]
+			}
+		}
+		if assignment == nil {
+			return ErrNotReviewer
+		}
+		allowed, err := auth.HasPermission(reviewerID, decisionPermissions[decision])
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrReviewForbidden
+		}
+
+		var content models.Content
+		if err := tx.Select("id", "version").First(&content, request.ContentID).Error; err != nil {
+			return err
+		}
+		current, err := versionTag(tx, &content)
+		if err != nil {
+			return err
+		}
+		if requested := VersionTag(request.Version, request.DraftVersion); current != requested {
+			return invalidFilter("version", "The content changed since review was requested (version "+
+				requested+", now "+current+"); request a new review")
+		}
+
+		now := time.Now()
+		assignment.Decision = decision
+		assignment.Note = note
+		assignment.DecidedAt = &now
+		if err := tx.Model(assignment).Select("decision", "note", "decided_at").Updates(assignment).Error; err != nil {
+			return err
+		}
+
+		request.Status = reviewStatus(request.Assignments)
+		if err := tx.Model(&request).Update("status", request.Status).Error; err != nil {
+			return err
+		}
+		return TriggerWebhooks(tx, "review."+strings.ToLower(decision), request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetReviewRequest(request.ID)
+}
+
+// reviewStatus is CHANGES_REQUESTED as soon as one reviewer asks for changes, APPROVED
+// once every reviewer approved and OPEN otherwise
+func reviewStatus(assignments []models.ReviewAssignment) string {
+	approved := 0
+	for _, a := range assignments {
+		switch a.Decision {
+		case models.ReviewChangesRequested:
+			return models.ReviewChangesRequested
+		case models.ReviewApproved:
+			approved++
+		}
+	}
+	if approved == len(assignments) {
+		return models.ReviewApproved
+	}
+	return models.ReviewOpen
+}
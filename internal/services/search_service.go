@@ -0,0 +1,268 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/renderer"
+	"encoding/json"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Full-text search keeps one index row per content item in a side table:
+//   - SQLite: FTS5 tables, content_search_en (porter stemming) for English and
+//     content_search (unicode61, no stemming) for every other language
+//   - PostgreSQL: content_search with a tsvector built using the text search
+//     configuration for the content's language (see searchConfigs)
+//
+// Rows are rewritten by indexContent whenever content is created, updated or reverted
+// and removed by unindexContent on delete.
+
+const (
+	searchTableStemmed = "content_search_en"
+	searchTable        = "content_search"
+	// Snippets come back from SQL with matches between these private use characters,
+	// so the text can be HTML-escaped before they become <mark> tags (see highlight)
+	snippetStart = "\uE000"
+	snippetEnd   = "\uE001"
+)
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetEnd, "</mark>")
+
+// searchConfigs maps Content.Language to a PostgreSQL text search configuration.
+// Languages not listed use "simple" (no stemming).
+var searchConfigs = map[string]string{
+	"ar": "arabic", "da": "danish", "de": "german", "en": "english", "es": "spanish",
+	"fi": "finnish", "fr": "french", "hu": "hungarian", "it": "italian", "nl": "dutch",
+	"no": "norwegian", "pt": "portuguese", "ro": "romanian", "ru": "russian",
+	"sv": "swedish", "tr": "turkish",
+}
+
+func searchConfig(language string) string {
+	if cfg, ok := searchConfigs[strings.ToLower(language)]; ok {
+		return cfg
+	}
+	return "simple"
+}
+
+// SetupSearchIndex creates the search tables and indexes existing content the first
+// time it runs. Call after AutoMigrate.
+func SetupSearchIndex() {
+	var statements []string
+	if database.IsPostgres() {
+		statements = []string{
+			`CREATE TABLE IF NOT EXISTS content_search (
+				content_id BIGINT PRIMARY KEY,
+				config REGCONFIG NOT NULL,
+				title TEXT NOT NULL DEFAULT '',
+				body TEXT NOT NULL DEFAULT '',
+				document TSVECTOR NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_content_search_document ON content_search USING GIN (document)`,
+		}
+	} else {
+		columns := "content_id UNINDEXED, title, body, attributes"
+		statements = []string{
+			"CREATE VIRTUAL TABLE IF NOT EXISTS " + searchTableStemmed + " USING fts5(" + columns + ", tokenize='porter unicode61 remove_diacritics 2')",
+			"CREATE VIRTUAL TABLE IF NOT EXISTS " + searchTable + " USING fts5(" + columns + ", tokenize='unicode61 remove_diacritics 2')",
+		}
+	}
+	for _, stmt := range statements {
+		if err := database.DB.Exec(stmt).Error; err != nil {
+			log.Println("Failed to set up search index:", err)
+			return
+		}
+	}
+
+	var indexed int64
+	database.DB.Raw("SELECT COUNT(*) FROM " + searchTable).Scan(&indexed)
+	if !database.IsPostgres() && indexed == 0 {
+		var stemmed int64
+		database.DB.Raw("SELECT COUNT(*) FROM " + searchTableStemmed).Scan(&stemmed)
+		indexed = stemmed
+	}
+	if indexed == 0 {
+		if err := ReindexAllContent(); err != nil {
+			log.Println("Failed to build search index:", err)
+		}
+	}
+}
+
+// ReindexAllContent rebuilds the search index from scratch
+func ReindexAllContent() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var contents []models.Content
+		if err := tx.Find(&contents).Error; err != nil {
+			return err
+		}
+		for i := range contents {
+			if err := indexContent(tx, &contents[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// indexContent replaces the index row for content. Blocks are indexed through the
+// plain text renderer so custom block types contribute whatever text they render.
+func indexContent(tx *gorm.DB, content *models.Content) error {
+	if err := unindexContent(tx, content.ID); err != nil {
+		return err
+	}
+
+	body := content.Body
+	if len(content.Blocks) > 0 {
+		text, err := RenderContent(content, renderer.Text)
+		if err != nil {
+			return err
+		}
+		body = strings.TrimSpace(body + "\n\n" + text)
+	}
+	attributes := strings.Join(attributeText(content.Attributes), " ")
+
+	if database.IsPostgres() {
+		cfg := searchConfig(content.Language)
+		return tx.Exec(`INSERT INTO content_search (content_id, config, title, body, document) VALUES (?, ?::regconfig, ?, ?,
+			setweight(to_tsvector(?::regconfig, ?), 'A') || setweight(to_tsvector(?::regconfig, ?), 'B') || setweight(to_tsvector(?::regconfig, ?), 'C'))`,
+			content.ID, cfg, content.Title, body, cfg, content.Title, cfg, attributes, cfg, body).Error
+	}
+
+	table := searchTable
+	if strings.EqualFold(content.Language, "en") {
+		table = searchTableStemmed
+	}
+	return tx.Exec("INSERT INTO "+table+" (content_id, title, body, attributes) VALUES (?, ?, ?, ?)",
+		content.ID, content.Title, body, attributes).Error
+}
+
+func unindexContent(tx *gorm.DB, contentID uint) error {
+	if database.IsPostgres() {
+		return tx.Exec("DELETE FROM content_search WHERE content_id = ?", contentID).Error
+	}
+	for _, table := range []string{searchTableStemmed, searchTable} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE content_id = ?", contentID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attributeText collects the string values of an attributes document, sorted so the
+// index text is stable
+func attributeText(attributes string) []string {
+	var doc interface{}
+	if json.Unmarshal([]byte(attributes), &doc) != nil {
+		return nil
+	}
+	var out []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			out = append(out, v)
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(v[k])
+			}
+		}
+	}
+	walk(doc)
+	return out
+}
+
+// ftsQuery turns free text into an FTS5 query: every word must match, the last one as
+// a prefix so results show up while typing. Returns "" when nothing is searchable.
+func ftsQuery(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = `"` + w + `"`
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// applySearch restricts query to matches of search and joins the relevance as
+// search.rank, where lower ranks are better on both dialects
+func applySearch(query *gorm.DB, search string) *gorm.DB {
+	if database.IsPostgres() {
+		return query.Joins(`JOIN (SELECT content_id, -ts_rank(document, websearch_to_tsquery(config, ?)) AS rank
+			FROM content_search WHERE document @@ websearch_to_tsquery(config, ?)) AS search ON search.content_id = contents.id`,
+			search, search)
+	}
+
+	match := ftsQuery(search)
+	if match == "" {
+		// Nothing searchable (only punctuation); keep search.rank resolvable for ordering
+		return query.Joins("JOIN (SELECT 0 AS content_id, 0 AS rank WHERE 1 = 0) AS search ON search.content_id = contents.id")
+	}
+	// bm25 weights follow the column order: content_id, title, body, attributes
+	return query.Joins(`JOIN (SELECT content_id, bm25(`+searchTableStemmed+`, 0, 10, 1, 2) AS rank FROM `+searchTableStemmed+` WHERE `+searchTableStemmed+` MATCH ?
+		UNION ALL SELECT content_id, bm25(`+searchTable+`, 0, 10, 1, 2) AS rank FROM `+searchTable+` WHERE `+searchTable+` MATCH ?) AS search
+		ON search.content_id = contents.id`, match, match)
+}
+
+// loadSnippets fills Content.Snippet with highlighted matches for one page of results
+func loadSnippets(contents []models.Content, search string) error {
+	if len(contents) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(contents))
+	for i := range contents {
+		ids[i] = contents[i].ID
+	}
+
+	var rows []struct {
+		ContentID uint
+		Snippet   string
+	}
+	var err error
+	if database.IsPostgres() {
+		err = database.DB.Raw(`SELECT content_id, ts_headline(config, title || ' ' || body, websearch_to_tsquery(config, ?),
+			'StartSel=`+snippetStart+`, StopSel=`+snippetEnd+`, MaxWords=24, MinWords=8') AS snippet
+			FROM content_search WHERE content_id IN ?`, search, ids).Scan(&rows).Error
+	} else {
+		match := ftsQuery(search)
+		snippet := func(table string) string {
+			return "SELECT content_id, snippet(" + table + ", -1, '" + snippetStart + "', '" + snippetEnd + "', '…', 16) AS snippet FROM " +
+				table + " WHERE " + table + " MATCH ? AND content_id IN ?"
+		}
+		err = database.DB.Raw(snippet(searchTableStemmed)+" UNION ALL "+snippet(searchTable), match, ids, match, ids).Scan(&rows).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	snippets := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		snippets[row.ContentID] = highlight(row.Snippet)
+	}
+	for i := range contents {
+		contents[i].Snippet = snippets[contents[i].ID]
+	}
+	return nil
+}
+
+// highlight turns a snippet from SQL into HTML: the indexed text is escaped and only the
+// match markers become markup
+func highlight(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
@@ -10,6 +10,7 @@ func SeedRBAC() {
 	// Define Permissions
 	perms := []string{
 		"content.create", "content.read", "content.update", "content.delete",
+		"content.review", "content.approve", "content.publish", // Workflow transitions
 		"comment.create", "comment.delete", // Engagement
 		"user.read", "user.update",
 		"system.settings",
@@ -24,9 +25,9 @@ func SeedRBAC() {
 
 	// Define Roles
 	roles := map[string][]string{
-		"Admin":  perms,                                                                                                                   // All
-		"Editor": {"content.create", "content.read", "content.update", "content.delete", "comment.create", "comment.delete", "user.read"}, // Can manage content
-		"Writer": {"content.create", "content.read", "content.update", "comment.create", "user.read"},                                     // Can write own content
+		"Admin":  perms,                                                                                                                                                                           // All
+		"Editor": {"content.create", "content.read", "content.update", "content.delete", "content.review", "content.approve", "content.publish", "comment.create", "comment.delete", "user.read"}, // Can manage and publish content
+		"Writer": {"content.create", "content.read", "content.update", "comment.create", "user.read"},                                                                                             // Can write own content
 	}
 
 	for roleName, permSlugs := range roles {
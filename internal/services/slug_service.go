@@ -0,0 +1,139 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/slug"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// GetContentBySlug finds content by its current slug, falling back to slugs recorded in
+// ContentSlugHistory. moved is true when the content was found through an old slug; only
+// ID, Slug and Language are loaded then, which is all a redirect needs. An empty language
+// matches any language, preferring the oldest item.
+func GetContentBySlug(slug, language string, viewer Viewer, fields *ContentFields) (content *models.Content, moved bool, err error) {
+	content = &models.Content{}
+	query := database.DB.Scopes(viewer.Scope, fields.Scope("slug", "language")).Where("contents.slug = ?", slug)
+	if language != "" {
+		query = query.Where("contents.language = ?", language)
+	}
+	err = query.Order("contents.id asc").First(content).Error
+	if err == nil {
+		return content, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	var history models.ContentSlugHistory
+	query = database.DB.Where("slug = ?", slug)
+	if language != "" {
+		query = query.Where("language = ?", language)
+	}
+	if err := query.Order("created_at desc").Order("id desc").First(&history).Error; err != nil {
+		return nil, false, err
+	}
+	content = &models.Content{}
+	if err := database.DB.Scopes(viewer.Scope).Select("id", "slug", "language").First(content, history.ContentID).Error; err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+// changeSlug validates the new slug/language pair and records the old one in the slug
+// history. A slug that is taken over again drops its stale history entries.
+func changeSlug(tx *gorm.DB, content *models.Content, newSlug, newLang string) error {
+	if newSlug == content.Slug && newLang == content.Language {
+		return nil
+	}
+
+	if err := checkContentSlug(tx, newSlug, newLang, content.ID); err != nil {
+		return err
+	}
+
+	if content.Slug != "" {
+		history := models.ContentSlugHistory{ContentID: content.ID, Slug: content.Slug, Language: content.Language}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("slug = ? AND language = ?", newSlug, newLang).Delete(&models.ContentSlugHistory{}).Error
+}
+
+// contentSlugTaken reports whether slug is used by another content in language.
+// Soft deleted rows count because they still hold the unique index.
+func contentSlugTaken(tx *gorm.DB, candidate, language string, exceptID uint) (bool, error) {
+	var count int64
+	err := tx.Unscoped().Model(&models.Content{}).Where("slug = ? AND language = ? AND id <> ?", candidate, language, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+func checkContentSlug(tx *gorm.DB, candidate, language string, exceptID uint) error {
+	taken, err := contentSlugTaken(tx, candidate, language, exceptID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return invalidFilter("slug", "Slug is already used by another "+language+" content")
+	}
+	return nil
+}
+
+// assignContentSlug generates a slug from the title when none was given, with a numeric
+// suffix on collisions within the language. Slugs still redirecting to other content are
+// skipped so old links keep working. Explicit slugs must be free.
+func assignContentSlug(tx *gorm.DB, content *models.Content) error {
+	if content.Slug != "" {
+		return checkContentSlug(tx, content.Slug, content.Language, content.ID)
+	}
+	generated, err := slug.Unique(slug.Make(content.Title), "content", func(candidate string) (bool, error) {
+		if taken, err := contentSlugTaken(tx, candidate, content.Language, content.ID); err != nil || taken {
+			return taken, err
+		}
+		var redirects int64
+		err := tx.Model(&models.ContentSlugHistory{}).Where("slug = ? AND language = ? AND content_id <> ?", candidate, content.Language, content.ID).Count(&redirects).Error
+		return redirects > 0, err
+	})
+	content.Slug = generated
+	return err
+}
+
+// uniqueSlug generates a slug for name that is free in the slug column of model
+func uniqueSlug(tx *gorm.DB, model interface{}, name, fallback string) (string, error) {
+	return slug.Unique(slug.Make(name), fallback, func(candidate string) (bool, error) {
+		var count int64
+		err := tx.Unscoped().Model(model).Where("slug = ?", candidate).Count(&count).Error
+		return count > 0, err
+	})
+}
+
+// syncTags finds tags by name and creates the missing ones with a generated slug
+func syncTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var tag models.Tag
+		err := tx.Where("name = ?", name).First(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			tag.Name = name
+			if tag.Slug, err = uniqueSlug(tx, &models.Tag{}, name, "tag"); err != nil {
+				return nil, err
+			}
+			err = tx.Create(&tag).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
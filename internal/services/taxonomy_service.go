@@ -3,16 +3,24 @@ package services
 import (
 	"content-flow/internal/database"
 	"content-flow/internal/models"
+	"content-flow/internal/pkgs/pagination"
 )
 
 // --- Categories ---
 
+// CreateCategory generates the slug from the name when slug is empty
 func CreateCategory(name, slug, description string) (*models.Category, error) {
 	category := &models.Category{
 		Name:        name,
 		Slug:        slug,
 		Description: description,
 	}
+	if category.Slug == "" {
+		var err error
+		if category.Slug, err = uniqueSlug(database.DB, &models.Category{}, name, "category"); err != nil {
+			return nil, err
+		}
+	}
 	err := database.DB.Create(category).Error
 	return category, err
 }
@@ -36,23 +44,7 @@ func GetCategoriesByIDs(ids []uint) ([]models.Category, error) {
 
 // SyncTags takes a list of tag names, finds existing ones, creates new ones, and returns the full list of Tag models.
 func SyncTags(tagNames []string) ([]models.Tag, error) {
-	var tags []models.Tag
-	if len(tagNames) == 0 {
-		return tags, nil
-	}
-
-	for _, name := range tagNames {
-		var tag models.Tag
-		// Find or Create
-		// Note: Ideally use FirstOrCreate, but slug generation might be needed if complicated
-		// For simplicity, assuming Slug = Name here or passed.
-		// Let's assume Slug = Name for simple tags
-		if err := database.DB.FirstOrCreate(&tag, models.Tag{Name: name, Slug: name}).Error; err != nil {
-			return nil, err
-		}
-		tags = append(tags, tag)
-	}
-	return tags, nil
+	return syncTags(database.DB, tagNames)
 }
 
 func GetAllTags() ([]models.Tag, error) {
@@ -60,3 +52,27 @@ func GetAllTags() ([]models.Tag, error) {
 	err := database.DB.Find(&tags).Error
 	return tags, err
 }
+
+// GetTagsPage is the keyset paginated form of GetAllTags. Tags carry no timestamps, so
+// they are paged by ID.
+func GetTagsPage(p pagination.Params) ([]models.Tag, pagination.Page, error) {
+	var tags []models.Tag
+	var page pagination.Page
+	p.Normalize()
+
+	query := database.DB.Model(&models.Tag{})
+	if !p.SkipTotal {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, page, err
+		}
+		page.HasTotal = true
+	}
+
+	if err := query.Scopes(pagination.ByID("tags", p.Cursor, p.Limit)).Find(&tags).Error; err != nil {
+		return nil, page, err
+	}
+	tags, page.NextCursor = pagination.Trim(tags, p.Limit, func(t models.Tag) pagination.Cursor {
+		return pagination.Cursor{ID: t.ID}
+	})
+	return tags, page, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"content-flow/internal/database"
 	"content-flow/internal/models"
+	"content-flow/internal/pkgs/pagination"
 	"errors"
 )
 
@@ -14,6 +15,27 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+func GetUserByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	return &user, nil
+}
+
+// GetUsersByIDs is the batch form of GetUserByID; unknown IDs are left out
+func GetUsersByIDs(ids []uint) (map[uint]*models.User, error) {
+	var users []models.User
+	if err := database.DB.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	return byID, nil
+}
+
 func UpdateUserProfile(userID uint, bio, avatar, fullName string) error {
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
@@ -27,13 +49,33 @@ func UpdateUserProfile(userID uint, bio, avatar, fullName string) error {
 	return database.DB.Save(&user).Error
 }
 
-func GetUserStories(authorID uint) ([]models.Content, error) {
+// GetUserStories lists the author's content visible to viewer: published stories for the
+// public, drafts too for the author themselves and editors
+func GetUserStories(authorID uint, viewer Viewer, fields *ContentFields) ([]models.Content, error) {
 	var stories []models.Content
-	// Only fetch Published stories for public view? Or all for author?
-	// For now let's just fetch all non-deleted.
-	// Typically public profiles show only published.
-	if err := database.DB.Where("author_id = ? AND status = 'PUBLISHED'", authorID).Order("created_at desc").Find(&stories).Error; err != nil {
+	if err := database.DB.Scopes(viewer.Scope, fields.Scope()).Where("author_id = ?", authorID).Order("created_at desc").Find(&stories).Error; err != nil {
 		return nil, err
 	}
 	return stories, nil
 }
+
+// GetUserStoriesPage is the keyset paginated form of GetUserStories
+func GetUserStoriesPage(authorID uint, viewer Viewer, p pagination.Params, fields *ContentFields) ([]models.Content, pagination.Page, error) {
+	var stories []models.Content
+	var page pagination.Page
+	p.Normalize()
+
+	query := database.DB.Model(&models.Content{}).Scopes(viewer.Scope).Where("contents.author_id = ?", authorID)
+	if !p.SkipTotal {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, page, err
+		}
+		page.HasTotal = true
+	}
+
+	if err := query.Scopes(fields.Scope("created_at"), pagination.Newest("contents", p.Cursor, p.Limit)).Find(&stories).Error; err != nil {
+		return nil, page, err
+	}
+	stories, page.NextCursor = pagination.Trim(stories, p.Limit, contentCursor)
+	return stories, page, nil
+}
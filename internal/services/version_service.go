@@ -0,0 +1,128 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// VersionConflictError is returned when a write was based on another version of the
+// content than the current one, i.e. someone else saved in between
+type VersionConflictError struct {
+	Current string // Current version tag
+}
+
+func (e *VersionConflictError) Error() string {
+	return "content was changed by someone else; the current version is " + e.Current
+}
+
+// VersionTag identifies the revision writes apply to: the content version, followed by
+// the draft number while a working draft exists, e.g. "5" or "5-d2". It is served as
+// the ETag of content.
+func VersionTag(version, draftVersion int) string {
+	tag := strconv.Itoa(version)
+	if draftVersion > 0 {
+		tag += "-d" + strconv.Itoa(draftVersion)
+	}
+	return tag
+}
+
+// ContentVersionTag returns the current version tag of content
+func ContentVersionTag(content *models.Content) (string, error) {
+	return versionTag(database.DB, content)
+}
+
+func versionTag(tx *gorm.DB, content *models.Content) (string, error) {
+	draftVersion, err := draftVersion(tx, content.ID)
+	if err != nil {
+		return "", err
+	}
+	return VersionTag(content.Version, draftVersion), nil
+}
+
+// draftVersion returns how often the draft of a content item was saved, or 0 when it has
+// none
+func draftVersion(tx *gorm.DB, contentID uint) (int, error) {
+	var versions []int
+	err := tx.Model(&models.ContentDraft{}).Where("content_id = ?", contentID).Pluck("version", &versions).Error
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	return versions[0], nil
+}
+
+// checkVersion compares the version tag a write was based on with the current one. An
+// empty expected tag skips the check.
+func checkVersion(tx *gorm.DB, content *models.Content, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	current, err := versionTag(tx, content)
+	if err != nil {
+		return err
+	}
+	if expected != current {
+		return &VersionConflictError{Current: current}
+	}
+	return nil
+}
+
+// bumpVersion moves content to its next version unless a concurrent write already did,
+// so of two saves based on the same version only one succeeds
+func bumpVersion(tx *gorm.DB, content *models.Content) error {
+	result := tx.Model(&models.Content{}).
+		Where("id = ? AND version = ?", content.ID, content.Version).
+		Update("version", content.Version+1)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return conflictWith(tx, content.ID)
+	}
+	content.Version++
+	return nil
+}
+
+// firstDraftVersion numbers a new draft of content past every draft it had before, so
+// neither an If-Match nor the approvals of a discarded draft apply to the new one
+func firstDraftVersion(tx *gorm.DB, content *models.Content) (int, error) {
+	var reviewed []int
+	if err := tx.Model(&models.ReviewRequest{}).Where("content_id = ? AND version = ?", content.ID, content.Version).
+		Pluck("COALESCE(draft_version, 0)", &reviewed).Error; err != nil {
+		return 0, err
+	}
+	last := content.LastDraftVersion
+	for _, v := range reviewed {
+		last = max(last, v)
+	}
+	return last + 1, nil
+}
+
+// bumpDraftVersion is bumpVersion for the saves of a draft
+func bumpDraftVersion(tx *gorm.DB, draft *models.ContentDraft) error {
+	result := tx.Model(&models.ContentDraft{}).
+		Where("id = ? AND version = ?", draft.ID, draft.Version).
+		Update("version", draft.Version+1)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return conflictWith(tx, draft.ContentID)
+	}
+	draft.Version++
+	return nil
+}
+
+func conflictWith(tx *gorm.DB, contentID uint) error {
+	var current models.Content
+	if err := tx.Select("id", "version").First(&current, contentID).Error; err != nil {
+		return err
+	}
+	tag, err := versionTag(tx, &current)
+	if err != nil {
+		return err
+	}
+	return &VersionConflictError{Current: tag}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/auth"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Viewer is who content is being read for. The zero Viewer is an anonymous visitor.
+//   - Anonymous: PUBLISHED items whose PublishedAt is unset or in the past and whose
+//     UnpublishAt is unset or still ahead, so embargoes hold between scheduler runs
+//   - Users with content.read: everything
+//   - Other users: the anonymous set plus their own items in any status
+type Viewer struct {
+	UserID     uint
+	CanReadAll bool
+}
+
+// Internal callers that act on content regardless of its status use this viewer
+var allContent = Viewer{CanReadAll: true}
+
+// ViewerFor resolves the permissions of userID (0 for anonymous)
+func ViewerFor(userID uint) (Viewer, error) {
+	viewer := Viewer{UserID: userID}
+	if userID == 0 {
+		return viewer, nil
+	}
+	canRead, err := auth.HasPermission(userID, "content.read")
+	if err != nil {
+		return viewer, err
+	}
+	viewer.CanReadAll = canRead
+	return viewer, nil
+}
+
+// Scope restricts a contents query to the items the viewer may see
+func (v Viewer) Scope(db *gorm.DB) *gorm.DB {
+	if v.CanReadAll {
+		return db
+	}
+	published := "contents.status = ? AND (contents.published_at IS NULL OR contents.published_at <= ?) AND (contents.unpublish_at IS NULL OR contents.unpublish_at > ?)"
+	now := time.Now()
+	if v.UserID == 0 {
+		return db.Where(published, models.StatusPublished, now, now)
+	}
+	return db.Where("(("+published+") OR contents.author_id = ?)", models.StatusPublished, now, now, v.UserID)
+}
+
+// CanView reports whether contentID exists and is visible to the viewer
+func CanView(contentID uint, viewer Viewer) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Content{}).Scopes(viewer.Scope).Where("contents.id = ?", contentID).Count(&count).Error
+	return count > 0, err
+}
@@ -4,29 +4,62 @@ import (
 	"bytes"
 	"content-flow/internal/database"
 	"content-flow/internal/models"
+	"content-flow/internal/pkgs/queue"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// TriggerWebhooks runs asynchronously to avoid blocking the API response
-func TriggerWebhooks(event string, payload interface{}) {
-	go func() {
-		var webhooks []models.Webhook
-		// content.create -> %content.create% logic or simple check
-		if err := database.DB.Where("enabled = ?", true).Find(&webhooks).Error; err != nil {
-			log.Println("Error fetching webhooks:", err)
-			return
-		}
+// WebhookDeliveryJob is the queue job type delivering one event to one webhook
+const WebhookDeliveryJob = "webhook.deliver"
+
+// webhookDelivery is the payload of a WebhookDeliveryJob. The body is rendered when the
+// event happens so retries send exactly what the first attempt sent.
+type webhookDelivery struct {
+	WebhookID uint            `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// TriggerWebhooks queues a delivery job per subscribed webhook on tx, the transaction
+// making the change, so an event is stored if and only if its change is (outbox).
+// Delivery happens in the background with retries (see deliverWebhook).
+func TriggerWebhooks(tx *gorm.DB, event string, payload interface{}) error {
+	var webhooks []models.Webhook
+	// content.create -> %content.create% logic or simple check
+	if err := tx.Where("enabled = ?", true).Find(&webhooks).Error; err != nil {
+		return fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
 
-		for _, wh := range webhooks {
-			if shouldTrigger(wh.Events, event) {
-				sendWebhook(wh.URL, event, payload)
+	var body json.RawMessage
+	for _, wh := range webhooks {
+		if !shouldTrigger(wh.Events, event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			body, err = json.Marshal(map[string]interface{}{
+				"event":     event,
+				"timestamp": time.Now().Unix(),
+				"data":      payload,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
 			}
 		}
-	}()
+		delivery := webhookDelivery{WebhookID: wh.ID, Event: event, Body: body}
+		if _, err := queue.Enqueue(tx, WebhookDeliveryJob, delivery, time.Time{}); err != nil {
+			return fmt.Errorf("failed to queue webhook %d for event %s: %w", wh.ID, event, err)
+		}
+	}
+	return nil
 }
 
 func shouldTrigger(registeredEvents, currentEvent string) bool {
@@ -43,18 +76,27 @@ func shouldTrigger(registeredEvents, currentEvent string) bool {
 	return false
 }
 
-func sendWebhook(url, event string, payload interface{}) {
-	body := map[string]interface{}{
-		"event":     event,
-		"timestamp": time.Now().Unix(),
-		"data":      payload,
+// deliverWebhook handles a WebhookDeliveryJob. Webhooks deleted or disabled since the
+// event was queued are skipped.
+func deliverWebhook(ctx context.Context, job *models.Job) error {
+	var delivery webhookDelivery
+	if err := queue.Decode(job, &delivery); err != nil {
+		return err
 	}
+	var wh models.Webhook
+	if err := database.DB.Where("enabled = ?", true).First(&wh, delivery.WebhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return sendWebhook(ctx, wh.URL, delivery.Event, delivery.Body)
+}
 
-	jsonBody, _ := json.Marshal(body)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+func sendWebhook(ctx context.Context, url, event string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
-		log.Println("Failed to create webhook request:", err)
-		return
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "ContentFlow-CMS-Webhook")
@@ -62,16 +104,15 @@ func sendWebhook(url, event string, payload interface{}) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("Failed to deliver webhook to %s: %v\n", url, err)
-		return
+		return fmt.Errorf("failed to deliver webhook to %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Printf("Webhook failed for %s with status %d\n", url, resp.StatusCode)
-	} else {
-		log.Printf("Webhook delivered to %s for event %s\n", url, event)
+		return fmt.Errorf("webhook failed for %s with status %d", url, resp.StatusCode)
 	}
+	log.Printf("Webhook delivered to %s for event %s\n", url, event)
+	return nil
 }
 
 // CRUD for Webhooks
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"content-flow/internal/database"
+	"content-flow/internal/models"
+	"content-flow/internal/pkgs/auth"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The editorial workflow is a state machine over Content.Status. Each allowed move is a
+// WorkflowTransition row naming the permission it requires; the defaults below are seeded
+// once and can then be changed through /api/workflow/transitions. Every transition taken
+// is recorded as a ContentTransition and announced with the transition's webhook event
+// (payload: the content) plus a generic "content.transition" event (payload: the
+// history entry).
+
+var (
+	// ErrTransitionForbidden is returned when the acting user lacks the transition's permission
+	ErrTransitionForbidden = errors.New("missing permission for workflow transition")
+	// ErrStatusChanged is returned when another request or instance changed the status first
+	ErrStatusChanged = errors.New("content status was changed concurrently")
+)
+
+// systemActor takes transitions on behalf of the scheduler; permissions are not checked
+const systemActor uint = 0
+
+var statusPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+var defaultTransitions = []models.WorkflowTransition{
+	{Name: "submit", FromStatus: models.StatusDraft, ToStatus: models.StatusInReview, Permission: "content.update"},
+	{Name: "approve", FromStatus: models.StatusInReview, ToStatus: models.StatusApproved, Permission: "content.approve"},
+	{Name: "reject", FromStatus: models.StatusInReview, ToStatus: models.StatusDraft, Permission: "content.review"},
+	{Name: "publish", FromStatus: models.StatusApproved, ToStatus: models.StatusPublished, Permission: "content.publish", RequiredApprovals: 1},
+	{Name: "schedule", FromStatus: models.StatusApproved, ToStatus: models.StatusScheduled, Permission: "content.publish", RequiredApprovals: 1},
+	{Name: "publish_draft", FromStatus: models.StatusDraft, ToStatus: models.StatusPublished, Permission: "content.publish", RequiredApprovals: 1},
+	{Name: "schedule_draft", FromStatus: models.StatusDraft, ToStatus: models.StatusScheduled, Permission: "content.publish", RequiredApprovals: 1},
+	{Name: "publish_scheduled", FromStatus: models.StatusScheduled, ToStatus: models.StatusPublished, Permission: "content.publish", RequiredApprovals: 1},
+	{Name: "unschedule", FromStatus: models.StatusScheduled, ToStatus: models.StatusDraft, Permission: "content.publish"},
+	{Name: "archive", FromStatus: models.StatusPublished, ToStatus: models.StatusArchived, Permission: "content.publish"},
+	{Name: "unpublish", FromStatus: models.StatusPublished, ToStatus: models.StatusDraft, Permission: "content.publish"},
+	{Name: "restore", FromStatus: models.StatusArchived, ToStatus: models.StatusDraft, Permission: "content.update"},
+}
+
+// SeedWorkflow installs the default transitions when none are configured yet. On existing
+// installs it raises the approvals of seeded transitions to the defaults.
+func SeedWorkflow() {
+	var count int64
+	database.DB.Model(&models.WorkflowTransition{}).Count(&count)
+	if count > 0 {
+		upgradeWorkflow()
+		return
+	}
+	for _, t := range defaultTransitions {
+		t.Event = transitionEvent(t.ToStatus)
+		if err := database.DB.Create(&t).Error; err != nil {
+			log.Println("Failed to seed workflow transition "+t.Name+":", err)
+		}
+	}
+	log.Println("Workflow seeded successfully")
+}
+
+// upgradeWorkflow applies the default approval rules to seeded transitions that were never
+// edited (created_at = updated_at), so installs from before the rules get them too. The
+// update bumps updated_at, so transitions changed through the API later are left alone.
+func upgradeWorkflow() {
+	for _, t := range defaultTransitions {
+		if t.RequiredApprovals == 0 {
+			continue
+		}
+		result := database.DB.Model(&models.WorkflowTransition{}).
+			Where("name = ? AND from_status = ? AND to_status = ? AND permission = ?", t.Name, t.FromStatus, t.ToStatus, t.Permission).
+			Where("required_approvals < ? AND created_at = updated_at", t.RequiredApprovals).
+			Update("required_approvals", t.RequiredApprovals)
+		if result.Error != nil {
+			log.Println("Failed to upgrade workflow transition "+t.Name+":", result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("Workflow transition %s now requires %d approval(s)", t.Name, t.RequiredApprovals)
+		}
+	}
+}
+
+func transitionEvent(toStatus string) string {
+	return "content." + strings.ToLower(toStatus)
+}
+
+// authorizeTransition finds the transition from -> to and checks that actorID may take it
+// and that enough reviewers approved the given version of the content
+func authorizeTransition(tx *gorm.DB, contentID uint, version int, from, to string, actorID uint) (*models.WorkflowTransition, error) {
+	var transition models.WorkflowTransition
+	err := tx.Where("from_status = ? AND to_status = ?", from, to).First(&transition).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, invalidFilter("status", "No workflow transition from "+from+" to "+to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if actorID == systemActor {
+		return &transition, nil
+	}
+	allowed, err := auth.HasPermission(actorID, transition.Permission)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrTransitionForbidden
+	}
+
+	if transition.RequiredApprovals > 0 {
+		approvals, err := countApprovals(tx, contentID, version)
+		if err != nil {
+			return nil, err
+		}
+		if approvals < int64(transition.RequiredApprovals) {
+			return nil, invalidFilter("status", fmt.Sprintf("%s to %s needs %d approval(s) of version %d, has %d",
+				from, to, transition.RequiredApprovals, version, approvals))
+		}
+	}
+	return &transition, nil
+}
+
+// checkSchedule validates the publish window: scheduled content needs a go-live date and
+// an expiry must come after it. A transition putting content live again (relaunch) starts
+// a new window, so an expiry that has already passed is cleared. Other changes keep it,
+// so the scheduler still takes expired content down.
+func checkSchedule(content *models.Content, relaunch bool) error {
+	if content.Status == models.StatusScheduled && content.PublishedAt == nil {
+		return invalidFilter("published_at", "published_at is required to schedule content")
+	}
+	if content.UnpublishAt == nil {
+		return nil
+	}
+	live := content.Status == models.StatusPublished || content.Status == models.StatusScheduled
+	if live && !content.UnpublishAt.After(time.Now()) {
+		if relaunch {
+			content.UnpublishAt = nil
+		}
+		return nil
+	}
+	if content.PublishedAt != nil && !content.UnpublishAt.After(*content.PublishedAt) {
+		return invalidFilter("unpublish_at", "unpublish_at must be after published_at")
+	}
+	return nil
+}
+
+// initialTransition handles the status of new content: it starts as a DRAFT, and any other
+// initial status must be reachable from DRAFT by a transition actorID may take. Returns
+// nil when the content stays a draft.
+func initialTransition(content *models.Content, actorID uint) (*models.WorkflowTransition, error) {
+	content.Status = strings.ToUpper(content.Status)
+	if content.Status == "" {
+		content.Status = models.StatusDraft
+	}
+	if content.Status == models.StatusDraft {
+		return nil, checkSchedule(content, false)
+	}
+	transition, err := authorizeTransition(database.DB, 0, 1, models.StatusDraft, content.Status, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return transition, checkSchedule(content, true)
+}
+
+func recordTransition(tx *gorm.DB, content *models.Content, transition *models.WorkflowTransition, from string, actorID uint, comment string) (*models.ContentTransition, error) {
+	entry := models.ContentTransition{
+		ContentID:  content.ID,
+		Transition: transition.Name,
+		FromStatus: from,
+		ToStatus:   transition.ToStatus,
+		UserID:     actorID,
+		Comment:    comment,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// notifyTransition queues the webhooks for a transition in the transaction taking it
+func notifyTransition(tx *gorm.DB, content models.Content, transition *models.WorkflowTransition, entry *models.ContentTransition) error {
+	event := transition.Event
+	if event == "" {
+		event = transitionEvent(transition.ToStatus)
+	}
+	if err := TriggerWebhooks(tx, event, content); err != nil {
+		return err
+	}
+	return TriggerWebhooks(tx, "content.transition", entry)
+}
+
+// TransitionContent moves content to another workflow status on behalf of actorID.
+// publishedAt sets the go-live date, e.g. when scheduling.
+func TransitionContent(id uint, to, comment string, publishedAt *time.Time, actorID uint) (*models.Content, error) {
+	var content models.Content
+	to = strings.ToUpper(strings.TrimSpace(to))
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&content, id).Error; err != nil {
+			return err
+		}
+		transition, entry, err := takeTransition(tx, &content, to, comment, publishedAt, actorID)
+		if err != nil {
+			return err
+		}
+		return notifyTransition(tx, content, transition, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// takeTransition moves loaded content to status to inside tx and records it. The caller
+// queues the webhooks with notifyTransition.
+func takeTransition(tx *gorm.DB, content *models.Content, to, comment string, publishedAt *time.Time, actorID uint) (*models.WorkflowTransition, *models.ContentTransition, error) {
+	if content.Status == to {
+		return nil, nil, invalidFilter("status", "Content is already "+to)
+	}
+
+	from := content.Status
+	transition, err := authorizeTransition(tx, content.ID, content.Version, from, to, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content.Status = to
+	if publishedAt != nil {
+		content.PublishedAt = publishedAt
+	}
+	if err := checkSchedule(content, true); err != nil {
+		return nil, nil, err
+	}
+	stampPublishedAt(content)
+	if err := claimStatus(tx, content.ID, from, to); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Model(content).Select("published_at", "unpublish_at").Updates(content).Error; err != nil {
+		return nil, nil, err
+	}
+
+	entry, err := recordTransition(tx, content, transition, from, actorID, comment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return transition, entry, nil
+}
+
+// claimStatus moves content from -> to only if it is still in from, so concurrent
+// transitions (e.g. two instances publishing the same scheduled item) cannot both win
+func claimStatus(tx *gorm.DB, contentID uint, from, to string) error {
+	result := tx.Model(&models.Content{}).Where("id = ? AND status = ?", contentID, from).Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// hasTransition reports whether a transition from -> to is configured
+func hasTransition(tx *gorm.DB, from, to string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.WorkflowTransition{}).Where("from_status = ? AND to_status = ?", from, to).Count(&count).Error
+	return count > 0, err
+}
+
+// countApprovals counts the reviewers who approved the given version of the content.
+// Approvals of a working draft are left out, since the draft is not what goes live.
+func countApprovals(tx *gorm.DB, contentID uint, version int) (int64, error) {
+	return countDraftApprovals(tx, contentID, version, 0)
+}
+
+// countDraftApprovals counts the reviewers who approved the given draft of a content version
+func countDraftApprovals(tx *gorm.DB, contentID uint, version, draftVersion int) (int64, error) {
+	var count int64
+	err := tx.Model(&models.ReviewAssignment{}).
+		Joins("JOIN review_requests ON review_requests.id = review_assignments.review_request_id").
+		Where("review_requests.content_id = ? AND review_requests.version = ? AND COALESCE(review_requests.draft_version, 0) = ? AND review_assignments.decision = ?", contentID, version, draftVersion, models.ReviewApproved).
+		Distinct("review_assignments.reviewer_id").
+		Count(&count).Error
+	return count, err
+}
+
+// draftApprovals returns how many approvals publishing a draft of content in status
+// needs: the most any transition into that status requires, since the draft goes live
+// in it without a transition of its own
+func draftApprovals(tx *gorm.DB, status string) (int, error) {
+	var required []int
+	if err := tx.Model(&models.WorkflowTransition{}).Where("to_status = ?", status).
+		Pluck("required_approvals", &required).Error; err != nil {
+		return 0, err
+	}
+	most := 0
+	for _, r := range required {
+		if r > most {
+			most = r
+		}
+	}
+	return most, nil
+}
+
+// GetContentTransitions returns the workflow history of a content item, newest first
+func GetContentTransitions(contentID uint) ([]models.ContentTransition, error) {
+	var history []models.ContentTransition
+	err := database.DB.Where("content_id = ?", contentID).Order("id desc").Find(&history).Error
+	return history, err
+}
+
+func GetWorkflowTransitions() ([]models.WorkflowTransition, error) {
+	var transitions []models.WorkflowTransition
+	err := database.DB.Order("from_status asc").Order("to_status asc").Find(&transitions).Error
+	return transitions, err
+}
+
+// normalizeTransition upper-cases the statuses, checks the permission exists and fills
+// in the default event
+func normalizeTransition(t *models.WorkflowTransition) error {
+	t.FromStatus = strings.ToUpper(strings.TrimSpace(t.FromStatus))
+	t.ToStatus = strings.ToUpper(strings.TrimSpace(t.ToStatus))
+	if !statusPattern.MatchString(t.FromStatus) {
+		return invalidFilter("from_status", "Status must be letters, digits and underscores")
+	}
+	if !statusPattern.MatchString(t.ToStatus) {
+		return invalidFilter("to_status", "Status must be letters, digits and underscores")
+	}
+	if t.FromStatus == t.ToStatus {
+		return invalidFilter("to_status", "to_status must differ from from_status")
+	}
+
+	var count int64
+	database.DB.Model(&models.Permission{}).Where("slug = ?", t.Permission).Count(&count)
+	if count == 0 {
+		return invalidFilter("permission", "Unknown permission "+t.Permission)
+	}
+
+	if t.Event == "" {
+		t.Event = transitionEvent(t.ToStatus)
+	}
+	return nil
+}
+
+func CreateWorkflowTransition(req *models.WorkflowTransitionRequest) (*models.WorkflowTransition, error) {
+	transition := models.WorkflowTransition{
+		Name:              req.Name,
+		FromStatus:        req.FromStatus,
+		ToStatus:          req.ToStatus,
+		Permission:        req.Permission,
+		Event:             req.Event,
+		RequiredApprovals: req.RequiredApprovals,
+	}
+	if err := normalizeTransition(&transition); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Create(&transition).Error; err != nil {
+		return nil, err
+	}
+	return &transition, nil
+}
+
+func UpdateWorkflowTransition(id uint, req *models.WorkflowTransitionRequest) (*models.WorkflowTransition, error) {
+	var transition models.WorkflowTransition
+	if err := database.DB.First(&transition, id).Error; err != nil {
+		return nil, err
+	}
+	transition.Name = req.Name
+	transition.FromStatus = req.FromStatus
+	transition.ToStatus = req.ToStatus
+	transition.Permission = req.Permission
+	transition.Event = req.Event
+	transition.RequiredApprovals = req.RequiredApprovals
+	if err := normalizeTransition(&transition); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Save(&transition).Error; err != nil {
+		return nil, err
+	}
+	return &transition, nil
+}
+
+func DeleteWorkflowTransition(id uint) error {
+	result := database.DB.Delete(&models.WorkflowTransition{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}